@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/git"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+var hexSHARE = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+type addressOptions struct {
+	Repo      string
+	Pull      int
+	Commit    string
+	Message   string
+	ThreadIDs []string
+}
+
+func newAddressCommand() *cobra.Command {
+	opts := &addressOptions{Commit: "HEAD"}
+
+	cmd := &cobra.Command{
+		Use:   "address <thread-id>...",
+		Short: "Reply with the fixing commit and resolve review threads",
+		Long: "Reply to each thread with a link to the commit that fixes it, then resolve the\n" +
+			"thread. The commit must already be pushed to the pull request branch.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ThreadIDs = args
+			return runAddress(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number (defaults to the pull request of the current branch)")
+	cmd.Flags().StringVar(&opts.Commit, "commit", opts.Commit, "Fixing commit: a SHA or a local revision such as HEAD")
+	cmd.Flags().StringVar(&opts.Message, "message", "", "Text posted above the commit link")
+
+	return cmd
+}
+
+func runAddress(cmd *cobra.Command, opts *addressOptions) error {
+	identity, err := resolver.Resolve("", opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	sha, err := resolveCommit(opts.Commit)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	commit, err := service.FindCommit(identity, sha)
+	if err != nil {
+		return err
+	}
+
+	threads, warnings, err := service.List(identity)
+	if err != nil {
+		return err
+	}
+	printWarnings(cmd, warnings)
+	known := make(map[string]bool, len(threads))
+	for _, thread := range threads {
+		known[thread.ID] = true
+	}
+
+	results := make([]comments.AddressResult, 0, len(opts.ThreadIDs))
+	failed := 0
+	for _, id := range opts.ThreadIDs {
+		id = strings.TrimSpace(id)
+		var result comments.AddressResult
+		if known[id] {
+			result = service.Address(id, commit, opts.Message)
+		} else {
+			result = comments.AddressResult{ThreadID: id, Error: fmt.Sprintf("thread not found on pull request #%d", identity.Number)}
+		}
+		if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := encodeJSON(cmd, map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"commit":       commit,
+		"threads":      results,
+	}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d threads could not be addressed", failed, len(results))
+	}
+	return nil
+}
+
+// resolveCommit turns a revision into a full SHA using the local repository.
+// A SHA that is not available locally is passed through, since the pull request
+// commits are authoritative.
+func resolveCommit(rev string) (string, error) {
+	sha, err := git.RevParse(rev)
+	if err == nil {
+		return sha, nil
+	}
+	if hexSHARE.MatchString(strings.TrimSpace(rev)) {
+		return strings.TrimSpace(rev), nil
+	}
+	return "", err
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/browse"
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+type browseOptions struct {
+	Repo     string
+	Pull     int
+	Selector string
+}
+
+func newBrowseCommand() *cobra.Command {
+	opts := &browseOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "browse [<number> | <url>]",
+		Short: "Browse and act on review threads in a full-screen terminal UI",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runBrowse(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+
+	return cmd
+}
+
+func runBrowse(cmd *cobra.Command, opts *browseOptions) error {
+	if debugSettings.Enabled {
+		return errors.New("--debug cannot be combined with browse; use --trace-file instead")
+	}
+
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	model := browse.New(service, identity, browse.OpenURL)
+	program := tea.NewProgram(model,
+		tea.WithAltScreen(),
+		tea.WithInput(cmd.InOrStdin()),
+		tea.WithOutput(cmd.OutOrStdout()),
+	)
+	_, err = program.Run()
+	return err
+}
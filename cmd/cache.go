@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+)
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local response cache",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached API responses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := ghcli.DefaultCacheDir()
+			if err != nil {
+				return err
+			}
+			if err := ghcli.ClearCache(dir); err != nil {
+				return err
+			}
+			return encodeJSON(cmd, map[string]interface{}{
+				"cleared": dir,
+			})
+		},
+	})
+
+	return cmd
+}
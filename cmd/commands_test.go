@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const (
+	testPatch   = "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"\n \n func main() {}"
+	testHunk    = "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\""
+	testCommit  = "e9bec7c235288a2ac3c29d273780b567c96294e2"
+	testThread  = "PRRT_seed1"
+	testComment = "PRRC_seed1"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// newTestBackend seeds acme/widgets#7 with one open thread and #8 touching the
+// same file, so threads can be imported across them.
+func newTestBackend(headSHA string) *ghfake.Backend {
+	backend := ghfake.New("github.com", "octocat")
+	pr := func(number int) *ghfake.PullRequest {
+		return &ghfake.PullRequest{
+			Owner:   "acme",
+			Repo:    "widgets",
+			Number:  number,
+			Title:   "Add widget",
+			Author:  "alice",
+			BaseRef: "main",
+			HeadRef: "feature",
+			HeadSHA: headSHA,
+			Files:   []ghfake.File{{Filename: "main.go", Patch: testPatch}},
+			Commits: []ghfake.Commit{{SHA: headSHA, Headline: "Add widget", Author: "alice"}},
+		}
+	}
+	seven := pr(7)
+	seven.Threads = []*ghfake.Thread{{
+		ID:           testThread,
+		Path:         "main.go",
+		Line:         intPtr(2),
+		OriginalLine: intPtr(2),
+		Comments: []*ghfake.Comment{{
+			ID:        testComment,
+			Author:    "bob",
+			Body:      "Why fmt?",
+			DiffHunk:  testHunk,
+			CommitSHA: headSHA,
+		}},
+	}}
+	seven.Reviews = []*ghfake.Review{{Author: "bob", State: "CHANGES_REQUESTED", Body: "see comments", SubmittedAt: "2026-01-01T00:00:00Z"}}
+	seven.IssueComments = []*ghfake.Comment{{Author: "carol", Body: "hi"}}
+	backend.AddPullRequest(seven)
+	backend.AddPullRequest(pr(8))
+	return backend
+}
+
+// forbiddenThread makes the fake withhold the first review thread, as GitHub
+// does for threads the token cannot read.
+func forbiddenThread(backend *ghfake.Backend) {
+	backend.GraphQLErrors = append(backend.GraphQLErrors, ghfake.SeededError{
+		Operation: "PullRequestInlineComments",
+		GraphQLErrorEntry: ghcli.GraphQLErrorEntry{
+			Type:    ghcli.GraphQLForbidden,
+			Message: "Resource not accessible by integration",
+			Path:    []interface{}{"repository", "pullRequest", "reviewThreads", "nodes", float64(0)},
+		},
+	})
+}
+
+// useBackend routes API and resolver calls to backend, metered from zero, for
+// the rest of the test.
+func useBackend(t *testing.T, backend *ghfake.Backend) {
+	t.Helper()
+	factory := apiClientFactory
+	settings := outputSettings
+	meta := includeMeta
+	meter := apiMeter
+	apiMeter = ghcli.NewMeter()
+	previous := resolver.UseRunner(backend.RunGh)
+	t.Cleanup(func() {
+		apiClientFactory = factory
+		outputSettings = settings
+		includeMeta = meta
+		apiMeter = meter
+		resolver.UseRunner(previous)
+	})
+	apiClientFactory = func(host string) ghcli.API {
+		return apiMeter.Wrap(backend)
+	}
+}
+
+// runCLI executes the command tree against backend and returns stdout and stderr.
+func runCLI(t *testing.T, backend *ghfake.Backend, args ...string) (string, string, error) {
+	t.Helper()
+	return runCLIWithInput(t, backend, "", args...)
+}
+
+// runCLIWithInput is runCLI with stdin set to input.
+func runCLIWithInput(t *testing.T, backend *ghfake.Backend, input string, args ...string) (string, string, error) {
+	t.Helper()
+	useBackend(t, backend)
+
+	var stdout, stderr bytes.Buffer
+	root := newRootCommand()
+	root.SetArgs(args)
+	root.SetIn(strings.NewReader(input))
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	err := root.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+// runSchemaCommand runs a command that must succeed and checks its output
+// against the command's schema.
+func runSchemaCommand(t *testing.T, backend *ghfake.Backend, command string, args ...string) map[string]interface{} {
+	t.Helper()
+	stdout, stderr, err := runCLI(t, backend, append([]string{command}, args...)...)
+	if err != nil {
+		t.Fatalf("%s: %v\nstderr: %s", command, err, stderr)
+	}
+	return assertMatchesSchema(t, command, []byte(stdout))
+}
+
+func TestListOutputMatchesSchema(t *testing.T) {
+	doc := runSchemaCommand(t, newTestBackend(testCommit), "list", "7", "-R", "acme/widgets")
+	if threads := doc["threads"].([]interface{}); len(threads) != 1 {
+		t.Fatalf("threads = %v, want the seeded thread", threads)
+	}
+	if _, ok := doc["warnings"]; ok {
+		t.Fatalf("unexpected warnings: %v", doc["warnings"])
+	}
+}
+
+func TestListReportsPartialDataWarnings(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	forbiddenThread(backend)
+
+	doc := runSchemaCommand(t, backend, "list", "7", "-R", "acme/widgets")
+	warnings, _ := doc["warnings"].([]interface{})
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", doc["warnings"])
+	}
+	if warning := warnings[0].(map[string]interface{}); warning["type"] != ghcli.GraphQLForbidden {
+		t.Fatalf("warning = %v, want a FORBIDDEN warning", warning)
+	}
+}
+
+func TestStatusOutputMatchesSchema(t *testing.T) {
+	runSchemaCommand(t, newTestBackend(testCommit), "status", "7", "-R", "acme/widgets")
+
+	backend := newTestBackend(testCommit)
+	forbiddenThread(backend)
+	doc := runSchemaCommand(t, backend, "status", "7", "-R", "acme/widgets")
+	if warnings, _ := doc["warnings"].([]interface{}); len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", doc["warnings"])
+	}
+}
+
+func TestSearchOutputMatchesSchema(t *testing.T) {
+	runSchemaCommand(t, newTestBackend(testCommit), "search", "-R", "acme/widgets")
+
+	backend := newTestBackend(testCommit)
+	forbiddenThread(backend)
+	runSchemaCommand(t, backend, "search", "-R", "acme/widgets")
+}
+
+func TestCreateOutputMatchesSchema(t *testing.T) {
+	doc := runSchemaCommand(t, newTestBackend(testCommit), "create", "7", "-R", "acme/widgets",
+		"--path", "main.go", "--line", "2", "--body", "Consider log instead")
+	comment := doc["comment"].(map[string]interface{})
+	if comment["body"] != "Consider log instead" {
+		t.Fatalf("comment = %v", comment)
+	}
+}
+
+func TestHideAndUnhideOutputMatchSchema(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	runSchemaCommand(t, backend, "hide", testComment, "--reason", "outdated")
+	runSchemaCommand(t, backend, "unhide", testComment)
+}
+
+func TestExportOutputMatchesSchema(t *testing.T) {
+	doc := runSchemaCommand(t, newTestBackend(testCommit), "export", "7", "-R", "acme/widgets")
+	if threads := doc["threads"].([]interface{}); len(threads) != 1 {
+		t.Fatalf("threads = %v, want the seeded thread", threads)
+	}
+}
+
+func TestExportShapesStdoutJSON(t *testing.T) {
+	stdout, _, err := runCLI(t, newTestBackend(testCommit), "export", "7", "-R", "acme/widgets", "--jq", ".threads | length")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if strings.TrimSpace(stdout) != "1" {
+		t.Fatalf("stdout = %q, want 1", stdout)
+	}
+
+	if _, _, err := runCLI(t, newTestBackend(testCommit), "export", "7", "-R", "acme/widgets", "--format", "markdown", "--jq", "."); err == nil {
+		t.Fatal("expected --jq to be rejected with --format markdown")
+	}
+}
+
+func TestImportOutputMatchesSchema(t *testing.T) {
+	doc := runSchemaCommand(t, newTestBackend(testCommit), "import", "8", "-R", "acme/widgets", "--from", "7")
+	imported := doc["imported"].([]interface{})
+	if len(imported) != 1 {
+		t.Fatalf("imported = %v, want one thread", imported)
+	}
+	if _, ok := imported[0].(map[string]interface{})["comment"]; !ok {
+		t.Fatalf("imported thread has no comment: %v", imported[0])
+	}
+}
+
+func TestAddressOutputMatchesSchema(t *testing.T) {
+	doc := runSchemaCommand(t, newTestBackend(testCommit), "address", testThread,
+		"-R", "acme/widgets", "--pr", "7", "--commit", testCommit)
+	threads := doc["threads"].([]interface{})
+	if len(threads) != 1 || threads[0].(map[string]interface{})["error"] != nil {
+		t.Fatalf("threads = %v, want one addressed thread", threads)
+	}
+}
+
+func TestCacheClearOutputMatchesSchema(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	runSchemaCommand(t, newTestBackend(testCommit), "cache", "clear")
+}
+
+// newGitRepo creates a repository whose second commit adds the fmt import and
+// carries a trailer addressing the seeded thread, and makes it the working
+// directory. It returns the SHAs of both commits.
+func newGitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		return runGitIn(t, dir, args...)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("package main\n\nfunc main() {}\n")
+	git("add", "main.go")
+	git("commit", "--quiet", "-m", "Initial commit")
+	base := git("rev-parse", "HEAD")
+	write("package main\nimport \"fmt\"\n\nfunc main() {}\n")
+	git("commit", "--quiet", "-am", "Add widget\n\n"+defaultTrailerKey+": "+testThread)
+	head := git("rev-parse", "HEAD")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return base, head
+}
+
+// runGitIn runs git in dir with a fixed identity and no user configuration.
+func runGitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestSyncTrailersOutputMatchesSchema(t *testing.T) {
+	base, head := newGitRepo(t)
+	doc := runSchemaCommand(t, newTestBackend(head), "sync-trailers", "7", "-R", "acme/widgets", "--range", base+"..HEAD")
+	results, _ := doc["results"].([]interface{})
+	if len(results) != 1 {
+		t.Fatalf("results = %v, want the trailer", doc["results"])
+	}
+}
+
+func TestThreadDiffOutputMatchesSchema(t *testing.T) {
+	_, head := newGitRepo(t)
+	runSchemaCommand(t, newTestBackend(head), "thread-diff", testThread)
+}
+
+func TestStatusFailOnUnresolvedRejectsPartialData(t *testing.T) {
+	resolved := func() *ghfake.Backend {
+		backend := newTestBackend(testCommit)
+		backend.PullRequests[0].Threads[0].IsResolved = true
+		forbiddenThread(backend)
+		return backend
+	}
+
+	stdout, _, err := runCLI(t, resolved(), "status", "7", "-R", "acme/widgets", "--fail-on-unresolved")
+	if err == nil || !strings.Contains(err.Error(), "--allow-partial") {
+		t.Fatalf("err = %v, want a failure on partial data", err)
+	}
+	assertMatchesSchema(t, "status", []byte(stdout))
+
+	if _, _, err := runCLI(t, resolved(), "status", "7", "-R", "acme/widgets", "--fail-on-unresolved", "--allow-partial"); err != nil {
+		t.Fatalf("--allow-partial: %v", err)
+	}
+}
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/agynio/gh-pr-review/internal/comments"
 	"github.com/agynio/gh-pr-review/internal/resolver"
+	"github.com/agynio/gh-pr-review/internal/sarif"
 )
 
 type createOptions struct {
@@ -17,6 +23,15 @@ type createOptions struct {
 	StartLine int
 	StartSide string
 	Body      string
+
+	Match      string
+	Regex      bool
+	Occurrence int
+
+	FromSARIF  string
+	SARIFRoot  string
+	ReviewBody string
+	DryRun     bool
 }
 
 func newCreateCommand() *cobra.Command {
@@ -42,14 +57,25 @@ func newCreateCommand() *cobra.Command {
 	cmd.Flags().IntVar(&opts.StartLine, "start-line", 0, "Start line for multi-line comments")
 	cmd.Flags().StringVar(&opts.StartSide, "start-side", "", "Start side for multi-line comments (LEFT or RIGHT)")
 	cmd.Flags().StringVar(&opts.Body, "body", "", "Comment body")
-	_ = cmd.MarkFlagRequired("path")
-	_ = cmd.MarkFlagRequired("line")
-	_ = cmd.MarkFlagRequired("body")
+	cmd.Flags().StringVar(&opts.Match, "match", "", "Anchor to the lines of the head version of --path (within the diff) containing this snippet, instead of --line")
+	cmd.Flags().BoolVar(&opts.Regex, "regex", false, "Treat --match as a regular expression")
+	cmd.Flags().IntVar(&opts.Occurrence, "occurrence", 0, "Which --match to use when the snippet matches several times (1-based)")
+	cmd.Flags().StringVar(&opts.FromSARIF, "from-sarif", "", "Post findings from a SARIF file as one review instead of a single comment")
+	cmd.Flags().StringVar(&opts.SARIFRoot, "sarif-root", "", "Directory SARIF paths are relative to (defaults to the current directory)")
+	cmd.Flags().StringVar(&opts.ReviewBody, "review-body", "", "Summary body for the review created by --from-sarif")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "With --from-sarif, report what would be posted without posting")
 
 	return cmd
 }
 
 func runCreate(cmd *cobra.Command, opts *createOptions) error {
+	if opts.FromSARIF != "" {
+		return runCreateFromSARIF(cmd, opts)
+	}
+	if err := requireCreateFlags(cmd); err != nil {
+		return err
+	}
+
 	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
 	if err != nil {
 		return err
@@ -57,6 +83,7 @@ func runCreate(cmd *cobra.Command, opts *createOptions) error {
 
 	service := comments.NewService(apiClientFactory(identity.Host))
 
+	line := opts.Line
 	var startLine *int
 	if opts.StartLine > 0 {
 		startLine = &opts.StartLine
@@ -66,9 +93,27 @@ func runCreate(cmd *cobra.Command, opts *createOptions) error {
 		startSide = &opts.StartSide
 	}
 
+	var match *comments.LineMatch
+	if cmd.Flags().Changed("match") {
+		matches, err := service.MatchLines(identity, opts.Path, opts.Match, opts.Regex)
+		if err != nil {
+			return err
+		}
+		selected, err := comments.SelectMatch(opts.Path, matches, opts.Occurrence)
+		if err != nil {
+			return err
+		}
+		match = &selected
+		line = selected.Line
+		if selected.StartLine < selected.Line {
+			start, side := selected.StartLine, "RIGHT"
+			startLine, startSide = &start, &side
+		}
+	}
+
 	created, err := service.Create(identity, comments.CreateInput{
 		Path:      opts.Path,
-		Line:      opts.Line,
+		Line:      line,
 		Side:      opts.Side,
 		StartLine: startLine,
 		StartSide: startSide,
@@ -78,14 +123,113 @@ func runCreate(cmd *cobra.Command, opts *createOptions) error {
 		return err
 	}
 
-	return encodeJSON(cmd, map[string]interface{}{
-		"pull_request": map[string]interface{}{
-			"owner":  identity.Owner,
-			"repo":   identity.Repo,
-			"host":   identity.Host,
-			"number": identity.Number,
-			"url":    identity.URL,
-		},
-		"comment": created,
-	})
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"comment":      created,
+	}
+	if match != nil {
+		payload["match"] = match
+	}
+	return encodeJSON(cmd, payload)
+}
+
+// requireCreateFlags enforces the single-comment flags, which are optional only
+// when --from-sarif supplies the comments. --match replaces --line.
+func requireCreateFlags(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	if flags.Changed("match") {
+		for _, name := range []string{"line", "start-line", "start-side"} {
+			if flags.Changed(name) {
+				return fmt.Errorf("--%s cannot be combined with --match", name)
+			}
+		}
+		if side, _ := flags.GetString("side"); !strings.EqualFold(strings.TrimSpace(side), "RIGHT") {
+			return errors.New("--match searches the head version; --side must be RIGHT")
+		}
+		if occurrence, _ := flags.GetInt("occurrence"); occurrence < 0 {
+			return errors.New("--occurrence must be a positive integer")
+		}
+	} else {
+		for _, name := range []string{"regex", "occurrence"} {
+			if flags.Changed(name) {
+				return fmt.Errorf("--%s requires --match", name)
+			}
+		}
+	}
+
+	required := []string{"path", "line", "body"}
+	if flags.Changed("match") {
+		required = []string{"path", "body"}
+	}
+	for _, name := range required {
+		if !flags.Changed(name) {
+			return fmt.Errorf("required flag \"%s\" not set", name)
+		}
+	}
+	return nil
+}
+
+func runCreateFromSARIF(cmd *cobra.Command, opts *createOptions) error {
+	for _, name := range []string{"path", "line", "body", "start-line", "start-side", "match", "regex", "occurrence"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be combined with --from-sarif", name)
+		}
+	}
+
+	root := opts.SARIFRoot
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("determine working directory: %w", err)
+		}
+		root = wd
+	}
+
+	file, err := os.Open(opts.FromSARIF)
+	if err != nil {
+		return fmt.Errorf("open sarif file: %w", err)
+	}
+	log, err := sarif.Read(file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	files, err := service.PullRequestFiles(identity)
+	if err != nil {
+		return err
+	}
+	existing, warnings, err := service.List(identity)
+	if err != nil {
+		return err
+	}
+	printWarnings(cmd, warnings)
+
+	plan := comments.PlanSARIF(sarif.Findings(log, root), files, existing)
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"dry_run":      opts.DryRun,
+		"planned":      len(plan.Inputs),
+		"posted":       0,
+		"skipped":      plan.Skipped,
+	}
+
+	if opts.DryRun || len(plan.Inputs) == 0 {
+		return encodeJSON(cmd, payload)
+	}
+
+	review, err := service.CreateReview(identity, opts.ReviewBody, plan.Inputs)
+	if err != nil {
+		return fmt.Errorf("post sarif review: %w", err)
+	}
+	payload["posted"] = len(plan.Inputs)
+	payload["review"] = review
+
+	return encodeJSON(cmd, payload)
 }
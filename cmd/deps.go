@@ -1,7 +1,167 @@
 package cmd
 
-import "github.com/agynio/gh-pr-review/internal/ghcli"
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const cacheEnv = "GH_PR_COMMENTS_CACHE"
+
+// cacheSettings is populated from the root command's persistent flags.
+var cacheSettings = struct {
+	Enabled  bool
+	Disabled bool
+	TTL      time.Duration
+}{TTL: time.Minute}
+
+// apiMeter accumulates the calls and rate-limit cost reported in the --meta block.
+var apiMeter = ghcli.NewMeter()
 
 var apiClientFactory = func(host string) ghcli.API {
-	return &ghcli.Client{Host: host}
+	// The meter reads REST quota from response headers, which forces --include
+	// on every call, so it only listens when the usage block is requested.
+	var meterTracer ghcli.Tracer
+	if includeMeta {
+		meterTracer = apiMeter
+	}
+	client := &ghcli.Client{Host: host, Tracer: ghcli.MultiTracer(activeTracer, meterTracer)}
+	api := apiMeter.Wrap(client)
+	if !cacheEnabled() {
+		return api
+	}
+	dir, err := ghcli.DefaultCacheDir()
+	if err != nil {
+		return api
+	}
+	// Without knowing whose token is in use, responses could leak between
+	// accounts, so the cache is skipped.
+	identity, err := ghcli.CredentialIdentity(host)
+	if err != nil {
+		return api
+	}
+	return ghcli.NewCache(api, host, identity, dir, cacheSettings.TTL)
+}
+
+// cacheEnabled reports whether reads should go through the disk cache: --cache or
+// GH_PR_COMMENTS_CACHE=1 opt in, and --no-cache always wins.
+func cacheEnabled() bool {
+	if cacheSettings.Disabled || cacheSettings.TTL <= 0 {
+		return false
+	}
+	if cacheSettings.Enabled {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(cacheEnv))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// Environment variables that swap the GitHub backend for offline use.
+const (
+	recordEnv = "GH_PR_COMMENTS_RECORD"
+	replayEnv = "GH_PR_COMMENTS_REPLAY"
+	fakeEnv   = "GH_PR_COMMENTS_FAKE"
+)
+
+// configureBackend routes API and `gh` calls through the in-memory fake or a
+// fixture replayer when requested via the environment, and optionally records
+// every exchange made against the selected backend to a fixture file.
+func configureBackend() error {
+	if path := os.Getenv(fakeEnv); path != "" {
+		backend, err := ghfake.Load(path)
+		if err != nil {
+			return err
+		}
+		resolver.UseRunner(backend.RunGh)
+		apiClientFactory = func(host string) ghcli.API {
+			return apiMeter.Wrap(backend)
+		}
+	} else if path := os.Getenv(replayEnv); path != "" {
+		replayer, err := ghcli.LoadReplayer(path)
+		if err != nil {
+			return err
+		}
+		resolver.UseRunner(replayer.RunGh)
+		apiClientFactory = func(host string) ghcli.API {
+			return apiMeter.Wrap(replayer.Client(host))
+		}
+	}
+
+	if path := os.Getenv(recordEnv); path != "" {
+		recorder := ghcli.NewRecorder(path)
+		previous := resolver.UseRunner(nil)
+		resolver.UseRunner(recorder.WrapRunner(previous))
+		base := apiClientFactory
+		apiClientFactory = func(host string) ghcli.API {
+			return recorder.Wrap(base(host), host)
+		}
+	}
+
+	return nil
+}
+
+const debugEnv = "GH_PR_COMMENTS_DEBUG"
+
+// debugSettings is populated from the root command's persistent flags.
+var debugSettings struct {
+	Enabled   bool
+	TraceFile string
+}
+
+var (
+	activeTracer ghcli.Tracer
+	traceOutput  *os.File
+)
+
+// configureTracing installs a tracer for API clients and resolver `gh` calls
+// when --debug, GH_PR_COMMENTS_DEBUG or --trace-file is set. Human-readable
+// lines go to stderr; --trace-file additionally receives one JSON event per line.
+func configureTracing() error {
+	enabled := debugSettings.Enabled
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(debugEnv))) {
+	case "1", "true", "yes", "on":
+		enabled = true
+	}
+	if !enabled && debugSettings.TraceFile == "" {
+		return nil
+	}
+
+	tracer := &ghcli.LogTracer{}
+	if enabled {
+		tracer.Log = os.Stderr
+	}
+	if debugSettings.TraceFile != "" {
+		file, err := os.Create(debugSettings.TraceFile)
+		if err != nil {
+			return fmt.Errorf("open trace file: %w", err)
+		}
+		traceOutput = file
+		tracer.File = file
+	}
+
+	activeTracer = tracer
+	previous := resolver.UseRunner(nil)
+	resolver.UseRunner(ghcli.TraceRunner(tracer, previous))
+	return nil
+}
+
+// closeTracing flushes the trace file, if one was opened.
+func closeTracing() error {
+	if traceOutput == nil {
+		return nil
+	}
+	err := traceOutput.Close()
+	traceOutput = nil
+	if err != nil {
+		return fmt.Errorf("close trace file: %w", err)
+	}
+	return nil
 }
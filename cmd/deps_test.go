@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEnabled(t *testing.T) {
+	settings := cacheSettings
+	t.Cleanup(func() { cacheSettings = settings })
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		disabled bool
+		ttl      time.Duration
+		env      string
+		want     bool
+	}{
+		{name: "off by default", ttl: time.Minute},
+		{name: "--cache", enabled: true, ttl: time.Minute, want: true},
+		{name: "env opt-in", env: "1", ttl: time.Minute, want: true},
+		{name: "env opt-in spelled out", env: "Yes", ttl: time.Minute, want: true},
+		{name: "env set to off", env: "0", ttl: time.Minute},
+		{name: "--no-cache beats --cache", enabled: true, disabled: true, ttl: time.Minute},
+		{name: "--no-cache beats env", disabled: true, env: "1", ttl: time.Minute},
+		{name: "zero ttl", enabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(cacheEnv, tt.env)
+			cacheSettings.Enabled = tt.enabled
+			cacheSettings.Disabled = tt.disabled
+			cacheSettings.TTL = tt.ttl
+			if got := cacheEnabled(); got != tt.want {
+				t.Fatalf("cacheEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+)
+
+const (
+	formatQuickfix       = "quickfix"
+	formatVSCodeProblems = "vscode-problems"
+	formatSARIF          = "sarif"
+)
+
+// writeQuickfix prints one `path:line:col: message` entry per diagnostic, which
+// Vim's default 'errorformat' and Emacs' compilation-mode both understand.
+func writeQuickfix(w io.Writer, diagnostics []comments.Diagnostic) error {
+	for _, d := range diagnostics {
+		message := d.Message
+		if d.EndLine > d.StartLine {
+			message = fmt.Sprintf("%s (lines %d-%d)", message, d.StartLine, d.EndLine)
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d:1: %s [%s]\n", d.Path, d.StartLine, message, d.ThreadID); err != nil {
+			return fmt.Errorf("write quickfix: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeVSCodeProblems prints `path:line:col:endLine:endCol: severity: message`
+// entries for a VS Code problem matcher. Outdated threads are reported as info.
+func writeVSCodeProblems(w io.Writer, diagnostics []comments.Diagnostic) error {
+	for _, d := range diagnostics {
+		severity := "warning"
+		if d.Outdated {
+			severity = "info"
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d:1:%d:1: %s: %s [%s]\n", d.Path, d.StartLine, d.EndLine, severity, d.Message, d.ThreadID); err != nil {
+			return fmt.Errorf("write problems: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// maxOperationSize bounds a single NDJSON operation line.
+const maxOperationSize = 4 << 20
+
+type execOptions struct {
+	Repo        string
+	Pull        int
+	Selector    string
+	StopOnError bool
+}
+
+func newExecCommand() *cobra.Command {
+	opts := &execOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "exec [<number> | <url>]",
+		Short: "Run comment operations read as NDJSON from stdin",
+		Long: "Read one JSON operation per line from stdin and run them in order against a single\n" +
+			"pull request, which is resolved once. Each operation has an \"op\" field:\n\n" +
+			"  {\"op\":\"create\",\"path\":\"main.go\",\"line\":12,\"body\":\"...\"}\n" +
+			"  {\"op\":\"reply\",\"thread_id\":\"PRRT_...\",\"body\":\"...\"}\n" +
+			"  {\"op\":\"resolve\",\"thread_id\":\"PRRT_...\"}\n" +
+			"  {\"op\":\"edit\",\"comment_id\":\"PRRC_...\",\"body\":\"...\"}\n" +
+			"  {\"op\":\"delete\",\"comment_id\":\"PRRC_...\"}\n" +
+			"  {\"op\":\"react\",\"comment_id\":\"PRRC_...\",\"reaction\":\"+1\"}\n\n" +
+			"create also accepts side, start_line and start_side. Any operation may carry an\n" +
+			"\"id\" that is echoed in its result. One result line is written per operation as\n" +
+			"soon as it finishes.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runExec(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().BoolVar(&opts.StopOnError, "stop-on-error", false, "Stop at the first failed operation instead of continuing")
+
+	return cmd
+}
+
+func runExec(cmd *cobra.Command, opts *execOptions) error {
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+	service := comments.NewService(apiClientFactory(identity.Host))
+
+	// The pull request node ID is looked up on the first create and reused for
+	// the rest of the batch.
+	var prID string
+	pullRequestID := func() (string, error) {
+		if prID == "" {
+			id, err := service.PullRequestID(identity)
+			if err != nil {
+				return "", err
+			}
+			prID = id
+		}
+		return prID, nil
+	}
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	scanner.Buffer(make([]byte, 0, 64*1024), maxOperationSize)
+
+	index, failed := 0, 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		index++
+
+		entry := map[string]interface{}{"index": index}
+		op, result, opErr := runOperation(service, pullRequestID, line)
+		if op.ID != nil {
+			entry["id"] = op.ID
+		}
+		if op.Op != "" {
+			entry["op"] = op.Op
+		}
+		if opErr != nil {
+			failed++
+			entry["ok"] = false
+			entry["error"] = opErr.Error()
+		} else {
+			entry["ok"] = true
+			entry["result"] = result
+		}
+		if err := encodeJSONLine(cmd, entry); err != nil {
+			return err
+		}
+		if opErr != nil && opts.StopOnError {
+			return fmt.Errorf("operation %d failed: %w", index, opErr)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read operations: %w", err)
+	}
+
+	if includeMeta {
+		if err := encodeJSONLine(cmd, map[string]interface{}{"meta": apiMeter.Usage()}); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d operations failed", failed, index)
+	}
+	return nil
+}
+
+// operationHeader holds the fields shared by every operation.
+type operationHeader struct {
+	Op string      `json:"op"`
+	ID interface{} `json:"id,omitempty"`
+}
+
+// runOperation decodes and executes one operation line. The header is returned
+// even on failure so the result can be correlated with its input. pullRequestID
+// supplies the node ID that create operations attach threads to.
+func runOperation(service *comments.Service, pullRequestID func() (string, error), line []byte) (operationHeader, interface{}, error) {
+	var header operationHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, nil, fmt.Errorf("invalid operation: %w", err)
+	}
+	header.Op = strings.ToLower(strings.TrimSpace(header.Op))
+
+	switch header.Op {
+	case "create":
+		var args struct {
+			operationHeader
+			Path      string  `json:"path"`
+			Line      int     `json:"line"`
+			Body      string  `json:"body"`
+			Side      string  `json:"side"`
+			StartLine *int    `json:"start_line"`
+			StartSide *string `json:"start_side"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		if strings.TrimSpace(args.Path) == "" {
+			return header, nil, errors.New("path is required")
+		}
+		if args.Line <= 0 {
+			return header, nil, errors.New("line must be a positive integer")
+		}
+		if args.Side == "" {
+			args.Side = "RIGHT"
+		}
+		prID, err := pullRequestID()
+		if err != nil {
+			return header, nil, err
+		}
+		created, err := service.CreateWithPR(prID, comments.CreateInput{
+			Path:      args.Path,
+			Line:      args.Line,
+			Side:      args.Side,
+			StartLine: args.StartLine,
+			StartSide: args.StartSide,
+			Body:      args.Body,
+		})
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"comment": created}, nil
+
+	case "reply":
+		var args struct {
+			operationHeader
+			ThreadID string `json:"thread_id"`
+			Body     string `json:"body"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		comment, err := service.Reply(args.ThreadID, args.Body)
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"thread_id": strings.TrimSpace(args.ThreadID), "comment": comment}, nil
+
+	case "resolve":
+		var args struct {
+			operationHeader
+			ThreadID string `json:"thread_id"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		thread, err := service.Resolve(args.ThreadID)
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"thread": thread}, nil
+
+	case "edit":
+		var args struct {
+			operationHeader
+			CommentID string `json:"comment_id"`
+			Body      string `json:"body"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		comment, err := service.Edit(args.CommentID, args.Body)
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"comment": comment}, nil
+
+	case "delete":
+		var args struct {
+			operationHeader
+			CommentID string `json:"comment_id"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		deleted, err := service.Delete(args.CommentID)
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"comment": deleted}, nil
+
+	case "react":
+		var args struct {
+			operationHeader
+			CommentID string `json:"comment_id"`
+			Reaction  string `json:"reaction"`
+		}
+		if err := decodeOperation(line, &args); err != nil {
+			return header, nil, err
+		}
+		reaction, err := service.React(args.CommentID, args.Reaction)
+		if err != nil {
+			return header, nil, err
+		}
+		return header, map[string]interface{}{"reaction": reaction}, nil
+
+	case "":
+		return header, nil, errors.New("op is required")
+	}
+	return header, nil, fmt.Errorf("unknown op %q: must be create, reply, resolve, edit, delete, or react", header.Op)
+}
+
+// decodeOperation strictly decodes an operation so misspelled fields fail
+// instead of being silently ignored.
+func decodeOperation(line []byte, target interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return fmt.Errorf("invalid operation: %w", err)
+	}
+	return nil
+}
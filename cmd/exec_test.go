@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+)
+
+// execRecords checks every NDJSON line written by exec against the exec schema.
+func execRecords(t *testing.T, stdout string) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if line != "" {
+			records = append(records, assertMatchesSchema(t, "exec", []byte(line)))
+		}
+	}
+	return records
+}
+
+func findThread(backend *ghfake.Backend, number int, id string) *ghfake.Thread {
+	for _, pr := range backend.PullRequests {
+		if pr.Number != number {
+			continue
+		}
+		for _, thread := range pr.Threads {
+			if thread.ID == id {
+				return thread
+			}
+		}
+	}
+	return nil
+}
+
+func TestExecRunsOperationsInOrder(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	input := strings.Join([]string{
+		`{"op":"create","id":"first","path":"main.go","line":2,"body":"Use log instead"}`,
+		`{"op":"reply","thread_id":"PRRT_seed1","body":"Dropped it"}`,
+		``,
+		`{"op":"edit","comment_id":"PRRC_seed1","body":"Why fmt here?"}`,
+		`{"op":"react","comment_id":"PRRC_seed1","reaction":"+1"}`,
+		`{"op":"create","id":2,"path":"main.go","line":1,"side":"right","body":"Package doc?"}`,
+		`{"op":"resolve","thread_id":"PRRT_seed1"}`,
+	}, "\n")
+
+	stdout, stderr, err := runCLIWithInput(t, backend, input, "exec", "7", "-R", "acme/widgets", "--meta")
+	if err != nil {
+		t.Fatalf("exec: %v\nstderr: %s", err, stderr)
+	}
+	records := execRecords(t, stdout)
+	if len(records) != 7 {
+		t.Fatalf("got %d records, want 6 results and a meta line:\n%s", len(records), stdout)
+	}
+
+	wantOps := []string{"create", "reply", "edit", "react", "create", "resolve"}
+	for i, op := range wantOps {
+		record := records[i]
+		if record["index"] != float64(i+1) || record["op"] != op || record["ok"] != true {
+			t.Errorf("record %d = %v, want index %d, op %s, ok", i, record, i+1, op)
+		}
+	}
+	if records[0]["id"] != "first" || records[4]["id"] != float64(2) {
+		t.Errorf("ids = %v, %v, want the input ids echoed", records[0]["id"], records[4]["id"])
+	}
+	if _, ok := records[1]["id"]; ok {
+		t.Errorf("record without an input id has id %v", records[1]["id"])
+	}
+
+	created := records[0]["result"].(map[string]interface{})["comment"].(map[string]interface{})
+	if created["path"] != "main.go" || created["line"] != float64(2) || created["body"] != "Use log instead" {
+		t.Errorf("created comment = %v", created)
+	}
+	reply := records[1]["result"].(map[string]interface{})
+	if reply["thread_id"] != testThread {
+		t.Errorf("reply thread_id = %v, want %s", reply["thread_id"], testThread)
+	}
+	reaction := records[3]["result"].(map[string]interface{})["reaction"].(map[string]interface{})
+	if reaction["subject_id"] != testComment || reaction["content"] != "thumbs_up" {
+		t.Errorf("reaction = %v", reaction)
+	}
+	resolved := records[5]["result"].(map[string]interface{})["thread"].(map[string]interface{})
+	if resolved["id"] != testThread || resolved["is_resolved"] != true {
+		t.Errorf("resolve result = %v", resolved)
+	}
+
+	// Six operations plus a single pull request lookup shared by both creates.
+	meta := records[6]["meta"].(map[string]interface{})
+	if meta["calls"] != float64(7) {
+		t.Errorf("meta calls = %v, want 7", meta["calls"])
+	}
+
+	thread := findThread(backend, 7, testThread)
+	if !thread.IsResolved || len(thread.Comments) != 2 || thread.Comments[0].Body != "Why fmt here?" {
+		t.Errorf("seeded thread = resolved %v, %d comments, first %q", thread.IsResolved, len(thread.Comments), thread.Comments[0].Body)
+	}
+	if got := len(backend.PullRequests[0].Threads); got != 3 {
+		t.Errorf("pull request has %d threads, want 3", got)
+	}
+}
+
+func TestExecContinuesPastFailures(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	input := strings.Join([]string{
+		`{"op":"reply","thread_id":"PRRT_missing","body":"hello"}`,
+		`{"op":"archive","thread_id":"PRRT_seed1"}`,
+		`not json`,
+		`{"op":"resolve","thread_id":"PRRT_seed1"}`,
+	}, "\n")
+
+	stdout, _, err := runCLIWithInput(t, backend, input, "exec", "7", "-R", "acme/widgets")
+	if err == nil || err.Error() != "3 of 4 operations failed" {
+		t.Fatalf("err = %v, want 3 of 4 operations failed", err)
+	}
+	records := execRecords(t, stdout)
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4:\n%s", len(records), stdout)
+	}
+	for i, record := range records[:3] {
+		if record["ok"] != false || record["error"] == "" || record["result"] != nil {
+			t.Errorf("record %d = %v, want a failure", i, record)
+		}
+	}
+	if msg := records[1]["error"].(string); !strings.Contains(msg, `unknown op "archive"`) {
+		t.Errorf("unknown op error = %q", msg)
+	}
+	if msg := records[2]["error"].(string); !strings.HasPrefix(msg, "invalid operation") {
+		t.Errorf("invalid line error = %q", msg)
+	}
+	if records[3]["ok"] != true {
+		t.Errorf("last record = %v, want success after earlier failures", records[3])
+	}
+	if !findThread(backend, 7, testThread).IsResolved {
+		t.Error("operation after the failures did not run")
+	}
+}
+
+func TestExecStopOnError(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	input := `{"op":"reply","thread_id":"PRRT_missing","body":"hello"}` + "\n" +
+		`{"op":"resolve","thread_id":"PRRT_seed1"}` + "\n"
+
+	stdout, _, err := runCLIWithInput(t, backend, input, "exec", "7", "-R", "acme/widgets", "--stop-on-error")
+	if err == nil || !strings.HasPrefix(err.Error(), "operation 1 failed: ") {
+		t.Fatalf("err = %v, want operation 1 failed", err)
+	}
+	records := execRecords(t, stdout)
+	if len(records) != 1 || records[0]["ok"] != false {
+		t.Fatalf("records = %v, want only the failed operation", records)
+	}
+	if findThread(backend, 7, testThread).IsResolved {
+		t.Error("operation after the failure ran despite --stop-on-error")
+	}
+}
+
+func TestExecRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		line  string
+		field string
+	}{
+		{`{"op":"reply","thread_id":"PRRT_seed1","text":"hello"}`, "text"},
+		{`{"op":"create","path":"main.go","line":2,"body":"x","sid":"LEFT"}`, "sid"},
+		{`{"op":"resolve","thread_id":"PRRT_seed1","comment_id":"PRRC_seed1"}`, "comment_id"},
+	}
+	for _, tt := range tests {
+		backend := newTestBackend(testCommit)
+		stdout, _, err := runCLIWithInput(t, backend, tt.line, "exec", "7", "-R", "acme/widgets")
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.line)
+		}
+		records := execRecords(t, stdout)
+		if len(records) != 1 {
+			t.Fatalf("%s: records = %v", tt.line, records)
+		}
+		if msg, _ := records[0]["error"].(string); !strings.Contains(msg, `unknown field "`+tt.field+`"`) {
+			t.Errorf("%s: error = %q, want the unknown field named", tt.line, msg)
+		}
+		thread := findThread(backend, 7, testThread)
+		if thread.IsResolved || len(thread.Comments) != 1 || len(backend.PullRequests[0].Threads) != 1 {
+			t.Errorf("%s: rejected operation changed the pull request", tt.line)
+		}
+	}
+}
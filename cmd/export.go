@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+type exportOptions struct {
+	Repo     string
+	Pull     int
+	Selector string
+
+	Format string
+	Output string
+	Schema bool
+}
+
+func newExportCommand() *cobra.Command {
+	opts := &exportOptions{Format: formatJSON}
+
+	cmd := &cobra.Command{
+		Use:   "export [<number> | <url>]",
+		Short: "Export a pull request's review discussion to a portable archive",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runExport(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "Output format: json or markdown")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Write the archive to a file instead of stdout")
+	cmd.Flags().BoolVar(&opts.Schema, "schema", false, "Print the JSON Schema of the archive format and exit")
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, opts *exportOptions) (err error) {
+	if opts.Schema {
+		_, err := cmd.OutOrStdout().Write(comments.ArchiveSchema())
+		return err
+	}
+
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format != formatJSON && format != formatMarkdown {
+		return fmt.Errorf("invalid format %q: must be %s or %s", opts.Format, formatJSON, formatMarkdown)
+	}
+
+	// The archive is a file format, so only JSON printed to stdout can be shaped.
+	if shapingRequested() && (format != formatJSON || opts.Output != "") {
+		return errors.New("--json, --jq and --template only apply to JSON export on stdout")
+	}
+
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	archive, err := service.Export(identity)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if opts.Output != "" {
+		file, createErr := os.Create(opts.Output)
+		if createErr != nil {
+			return fmt.Errorf("create output file: %w", createErr)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("close output file: %w", closeErr)
+			}
+		}()
+		out = file
+	}
+
+	if format == formatMarkdown {
+		return comments.WriteArchiveMarkdown(out, archive)
+	}
+	if shapingRequested() {
+		shaped, err := shapeOutput(archive)
+		if err != nil {
+			return err
+		}
+		return writeOutput(cmd, shaped)
+	}
+	return writeIndentedJSON(out, archive)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+)
+
+type hideOptions struct {
+	Hostname   string
+	Reason     string
+	CommentIDs []string
+}
+
+func newHideCommand() *cobra.Command {
+	opts := &hideOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "hide <comment-id>...",
+		Short: "Minimize (hide) pull request comments",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.CommentIDs = args
+			return runHide(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Hostname, "hostname", "", "GitHub host (defaults to the gh default host)")
+	cmd.Flags().StringVar(&opts.Reason, "reason", "", "Reason: outdated, resolved, duplicate, off-topic, spam, or abuse")
+	_ = cmd.MarkFlagRequired("reason")
+
+	return cmd
+}
+
+func newUnhideCommand() *cobra.Command {
+	opts := &hideOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "unhide <comment-id>...",
+		Short: "Unminimize previously hidden pull request comments",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.CommentIDs = args
+			return runUnhide(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Hostname, "hostname", "", "GitHub host (defaults to the gh default host)")
+
+	return cmd
+}
+
+func runHide(cmd *cobra.Command, opts *hideOptions) error {
+	service := comments.NewService(apiClientFactory(opts.Hostname))
+	return writeMinimizeResults(cmd, opts.CommentIDs, "hidden", func(id string) (comments.MinimizeResult, error) {
+		return service.Minimize(id, opts.Reason)
+	})
+}
+
+func runUnhide(cmd *cobra.Command, opts *hideOptions) error {
+	service := comments.NewService(apiClientFactory(opts.Hostname))
+	return writeMinimizeResults(cmd, opts.CommentIDs, "unhidden", service.Unminimize)
+}
+
+// writeMinimizeResults applies op to every comment, recording failures per
+// comment so the output shows which comments changed, and fails afterwards if
+// any of them could not be updated.
+func writeMinimizeResults(cmd *cobra.Command, ids []string, verb string, op func(string) (comments.MinimizeResult, error)) error {
+	results := make([]comments.MinimizeResult, 0, len(ids))
+	failed := 0
+	for _, id := range ids {
+		result, err := op(id)
+		if err != nil {
+			result = comments.MinimizeResult{ID: strings.TrimSpace(id), Error: err.Error()}
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := encodeJSON(cmd, map[string]interface{}{
+		"comments": results,
+	}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d comments could not be %s", failed, len(results), verb)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+)
+
+func TestHideReportsEachCommentAndFailsAfterwards(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	stdout, _, err := runCLI(t, backend, "hide", testComment, "PRRC_missing", "--reason", "outdated")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 comments") {
+		t.Fatalf("err = %v, want one failed comment", err)
+	}
+
+	doc := assertMatchesSchema(t, "hide", []byte(stdout))
+	results := doc["comments"].([]interface{})
+	if len(results) != 2 {
+		t.Fatalf("comments = %v, want both ids", results)
+	}
+	hidden := results[0].(map[string]interface{})
+	if hidden["id"] != testComment || hidden["is_minimized"] != true || hidden["error"] != nil {
+		t.Fatalf("first result = %v, want the hidden comment", hidden)
+	}
+	missing := results[1].(map[string]interface{})
+	if missing["id"] != "PRRC_missing" || missing["error"] == nil {
+		t.Fatalf("second result = %v, want an error", missing)
+	}
+	if !backend.PullRequests[0].Threads[0].Comments[0].IsMinimized {
+		t.Fatal("the first comment was not hidden")
+	}
+}
+
+func TestHideRejectsUnknownReasonPerComment(t *testing.T) {
+	stdout, _, err := runCLI(t, newTestBackend(testCommit), "hide", testComment, "--reason", "rude")
+	if err == nil {
+		t.Fatal("expected an error for an unknown reason")
+	}
+	var doc struct {
+		Comments []struct {
+			Error string `json:"error"`
+		} `json:"comments"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(doc.Comments) != 1 || !strings.Contains(doc.Comments[0].Error, "invalid reason") {
+		t.Fatalf("comments = %+v, want an invalid reason error", doc.Comments)
+	}
+}
+
+func TestListExcludeMinimized(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	backend.PullRequests[0].Threads[0].Comments[0].IsMinimized = true
+	backend.PullRequests[0].Threads[0].Comments[0].MinimizedReason = "OUTDATED"
+
+	doc := runSchemaCommand(t, backend, "list", "7", "-R", "acme/widgets")
+	if threads := doc["threads"].([]interface{}); len(threads) != 1 {
+		t.Fatalf("threads = %v, want the hidden thread without --exclude-minimized", threads)
+	}
+
+	doc = runSchemaCommand(t, backend, "list", "7", "-R", "acme/widgets", "--exclude-minimized")
+	if threads := doc["threads"].([]interface{}); len(threads) != 0 {
+		t.Fatalf("threads = %v, want none with --exclude-minimized", threads)
+	}
+}
+
+func TestListRecreateQuotesMinimizedComments(t *testing.T) {
+	backend := newTestBackend(testCommit)
+	pr := backend.PullRequests[0]
+	pr.Files[0].Content = "package main\n\nimport \"fmt\"\n\nfunc main() {}\n"
+	thread := pr.Threads[0]
+	thread.IsOutdated = true
+	thread.Line = nil
+	thread.Comments = append(thread.Comments, &ghfake.Comment{
+		ID:              "PRRC_reply",
+		Author:          "carol",
+		Body:            "Same question",
+		URL:             "https://github.com/acme/widgets/pull/7#discussion_r99",
+		IsMinimized:     true,
+		MinimizedReason: "DUPLICATE",
+	})
+
+	doc := runSchemaCommand(t, backend, "list", "7", "-R", "acme/widgets", "--recreate", "--exclude-minimized")
+	if len(pr.Threads) != 2 {
+		t.Fatalf("pull request has %d threads, want the recreated one", len(pr.Threads))
+	}
+	if body := pr.Threads[1].Comments[0].Body; !strings.Contains(body, "Same question") {
+		t.Errorf("recreated body does not quote the minimized reply:\n%s", body)
+	}
+
+	for _, item := range doc["threads"].([]interface{}) {
+		for _, c := range item.(map[string]interface{})["comments"].([]interface{}) {
+			if c.(map[string]interface{})["id"] == "PRRC_reply" {
+				t.Error("--exclude-minimized kept the minimized reply")
+			}
+		}
+	}
+}
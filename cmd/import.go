@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+type importOptions struct {
+	Repo     string
+	Pull     int
+	Selector string
+
+	From   string
+	DryRun bool
+}
+
+func newImportCommand() *cobra.Command {
+	opts := &importOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "import [<number> | <url>] --from <number | url | export-file>",
+		Short: "Re-create unresolved review threads from another pull request or an export",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runImport(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Source pull request (number or URL) or a file written by 'export'")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Report where threads would be placed without creating them")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+func runImport(cmd *cobra.Command, opts *importOptions) error {
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	archive, err := loadImportSource(opts)
+	if err != nil {
+		return err
+	}
+	if archive.PullRequest.URL != "" && archive.PullRequest.URL == identity.URL {
+		return errors.New("source and target pull requests are the same")
+	}
+
+	// The report is printed even when some threads failed, so the threads that
+	// were created are known before rerunning.
+	report, importErr := service.Import(identity, archive, opts.DryRun)
+	if importErr != nil && len(report.Imported) == 0 && len(report.Unplaced) == 0 {
+		return importErr
+	}
+	printWarnings(cmd, report.Warnings)
+
+	if err := encodeJSON(cmd, map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"source": map[string]interface{}{
+			"owner":  archive.PullRequest.Owner,
+			"repo":   archive.PullRequest.Repo,
+			"host":   archive.PullRequest.Host,
+			"number": archive.PullRequest.Number,
+			"url":    archive.PullRequest.URL,
+		},
+		"dry_run":  opts.DryRun,
+		"imported": report.Imported,
+		"unplaced": report.Unplaced,
+		"skipped":  report.Skipped,
+	}); err != nil {
+		return err
+	}
+	return importErr
+}
+
+// loadImportSource reads an export file when --from names an existing file, and
+// otherwise exports the referenced pull request on the fly.
+func loadImportSource(opts *importOptions) (comments.Archive, error) {
+	from := strings.TrimSpace(opts.From)
+	if info, err := os.Stat(from); err == nil && info.Mode().IsRegular() {
+		file, err := os.Open(from)
+		if err != nil {
+			return comments.Archive{}, fmt.Errorf("open export file: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		return comments.ReadArchive(file)
+	}
+
+	source, err := resolver.Resolve(from, 0, opts.Repo)
+	if err != nil {
+		return comments.Archive{}, err
+	}
+	return comments.NewService(apiClientFactory(source.Host)).Export(source)
+}
@@ -1,20 +1,40 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/diff"
 	"github.com/agynio/gh-pr-review/internal/resolver"
 )
 
+const (
+	includeReviews       = "reviews"
+	includeIssueComments = "issue-comments"
+)
+
 type listOptions struct {
 	Repo     string
 	Pull     int
 	Selector string
+
+	ExcludeMinimized  bool
+	ExcludeBots       bool
+	AuthorAssociation []string
+	Include           []string
+	Timeline          bool
+	Format            string
+	IncludeOutdated   bool
+	Relocate          bool
+	Recreate          bool
+	MinConfidence     float64
 }
 
 func newListCommand() *cobra.Command {
-	opts := &listOptions{}
+	opts := &listOptions{Format: formatJSON, MinConfidence: 0.8}
 
 	cmd := &cobra.Command{
 		Use:   "list [<number> | <url>]",
@@ -30,30 +50,171 @@ func newListCommand() *cobra.Command {
 
 	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
 	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().BoolVar(&opts.ExcludeMinimized, "exclude-minimized", false, "Omit hidden comments and threads with no visible comments")
+	cmd.Flags().BoolVar(&opts.ExcludeBots, "exclude-bots", false, "Omit comments and reviews by bots, and threads left without comments")
+	cmd.Flags().StringSliceVar(&opts.AuthorAssociation, "author-association", nil, "Only keep comments and reviews whose author has one of these associations (e.g. owner, member, collaborator)")
+	cmd.Flags().StringSliceVar(&opts.Include, "include", nil, "Additional discussion to fetch: reviews, issue-comments")
+	cmd.Flags().BoolVar(&opts.Timeline, "timeline", false, "Add a unified, time-ordered timeline of all fetched comments")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "Output format: json, quickfix, vscode-problems, or sarif")
+	cmd.Flags().BoolVar(&opts.IncludeOutdated, "include-outdated", false, "With quickfix/vscode-problems, flag outdated threads instead of skipping them")
+	cmd.Flags().BoolVar(&opts.Relocate, "relocate", false, "Locate the code of outdated threads in the current head")
+	cmd.Flags().BoolVar(&opts.Recreate, "recreate", false, "Move relocated, unresolved outdated threads to a new thread at their current position (implies --relocate)")
+	cmd.Flags().Float64Var(&opts.MinConfidence, "min-confidence", opts.MinConfidence, "Lowest relocation confidence accepted by --recreate")
 
 	return cmd
 }
 
 func runList(cmd *cobra.Command, opts *listOptions) error {
+	withReviews, withIssueComments, err := parseInclude(opts.Include)
+	if err != nil {
+		return err
+	}
+
+	if opts.MinConfidence < 0 || opts.MinConfidence > 1 {
+		return fmt.Errorf("--min-confidence must be between 0 and 1")
+	}
+
+	associations, err := comments.ParseAuthorAssociations(opts.AuthorAssociation)
+	if err != nil {
+		return err
+	}
+	authors := comments.AuthorFilter{ExcludeBots: opts.ExcludeBots, Associations: associations}
+
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	switch format {
+	case formatJSON:
+	case formatQuickfix, formatVSCodeProblems, formatSARIF:
+		if withReviews || withIssueComments || opts.Timeline {
+			return fmt.Errorf("--include and --timeline require --format %s", formatJSON)
+		}
+		if opts.Recreate {
+			return fmt.Errorf("--recreate requires --format %s", formatJSON)
+		}
+	default:
+		return fmt.Errorf("invalid format %q: must be %s, %s, %s, or %s", opts.Format, formatJSON, formatQuickfix, formatVSCodeProblems, formatSARIF)
+	}
+
 	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
 	if err != nil {
 		return err
 	}
 
 	service := comments.NewService(apiClientFactory(identity.Host))
-	threads, err := service.List(identity)
+	threads, warnings, err := service.List(identity)
 	if err != nil {
 		return err
 	}
+	if opts.Relocate || opts.Recreate {
+		threads, err = service.Relocate(identity, threads)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.Recreate {
+		if err := recreateThreads(service, identity, threads, opts.MinConfidence); err != nil {
+			return err
+		}
+	}
+	// Filter after recreating so moved threads still quote the whole conversation.
+	if opts.ExcludeMinimized {
+		threads = comments.FilterMinimized(threads)
+	}
+	threads = comments.FilterThreadAuthors(threads, authors)
 
-	return encodeJSON(cmd, map[string]interface{}{
-		"pull_request": map[string]interface{}{
-			"owner":  identity.Owner,
-			"repo":   identity.Repo,
-			"host":   identity.Host,
-			"number": identity.Number,
-			"url":    identity.URL,
-		},
-		"threads": threads,
-	})
+	if format != formatJSON {
+		printWarnings(cmd, warnings)
+	}
+	switch format {
+	case formatQuickfix:
+		return writeQuickfix(cmd.OutOrStdout(), comments.Diagnostics(threads, opts.IncludeOutdated))
+	case formatVSCodeProblems:
+		return writeVSCodeProblems(cmd.OutOrStdout(), comments.Diagnostics(threads, opts.IncludeOutdated))
+	case formatSARIF:
+		return encodeJSON(cmd, comments.ThreadsSARIF(threads))
+	}
+
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"threads":      threads,
+	}
+	if len(warnings) > 0 {
+		payload["warnings"] = warnings
+	}
+
+	var reviews []comments.Review
+	if withReviews {
+		reviews, err = service.ListReviews(identity)
+		if err != nil {
+			return err
+		}
+		reviews = comments.FilterReviewAuthors(reviews, authors)
+		payload["reviews"] = reviews
+	}
+
+	var issueComments []comments.IssueComment
+	if withIssueComments {
+		issueComments, err = service.ListIssueComments(identity)
+		if err != nil {
+			return err
+		}
+		if opts.ExcludeMinimized {
+			issueComments = comments.FilterMinimizedIssueComments(issueComments)
+		}
+		issueComments = comments.FilterIssueCommentAuthors(issueComments, authors)
+		payload["issue_comments"] = issueComments
+	}
+
+	if opts.Timeline {
+		payload["timeline"] = comments.BuildTimeline(threads, reviews, issueComments)
+	}
+
+	return encodeJSON(cmd, payload)
+}
+
+// recreateThreads moves each unresolved outdated thread whose relocation is
+// confident enough. Per-thread failures are recorded on the relocation.
+func recreateThreads(service *comments.Service, identity resolver.Identity, threads []comments.Thread, minConfidence float64) error {
+	var files []diff.File
+	for i := range threads {
+		relocation := threads[i].Relocation
+		if relocation == nil || threads[i].IsResolved || relocation.CurrentLine == 0 {
+			continue
+		}
+		if relocation.Confidence < minConfidence {
+			relocation.RecreateError = fmt.Sprintf("confidence %.2f is below --min-confidence %.2f", relocation.Confidence, minConfidence)
+			continue
+		}
+		if files == nil {
+			var err error
+			if files, err = service.PullRequestFiles(identity); err != nil {
+				return err
+			}
+		}
+		created, err := service.Recreate(identity, threads[i], files)
+		if created.ThreadID != "" {
+			relocation.Recreated = &created
+		}
+		if err != nil {
+			relocation.RecreateError = err.Error()
+			continue
+		}
+		threads[i].IsResolved = true
+	}
+	return nil
+}
+
+func parseInclude(values []string) (bool, bool, error) {
+	var withReviews, withIssueComments bool
+	for _, value := range values {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case includeReviews:
+			withReviews = true
+		case includeIssueComments:
+			withIssueComments = true
+		case "":
+		default:
+			return false, false, fmt.Errorf("invalid --include value %q: must be %s or %s", value, includeReviews, includeIssueComments)
+		}
+	}
+	return withReviews, withIssueComments, nil
 }
@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/mcp"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+func newMCPCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Run a Model Context Protocol server on stdio",
+		Long: "Run a Model Context Protocol (MCP) server that reads JSON-RPC messages from stdin\n" +
+			"and exposes comment operations as tools. API clients and resolved pull requests\n" +
+			"are reused for the whole session.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMCP(cmd)
+		},
+	}
+}
+
+func runMCP(cmd *cobra.Command) error {
+	session := newMCPSession()
+	server := mcp.NewServer("gh-pr-comments", buildVersion())
+	for _, tool := range session.tools() {
+		server.AddTool(tool)
+	}
+	return server.Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+}
+
+// buildVersion reports the module version when installed with `go install`.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// mcpSession holds the state shared by tool calls: one service per host and
+// the identities of pull requests already resolved.
+type mcpSession struct {
+	services   map[string]*comments.Service
+	identities map[string]resolver.Identity
+}
+
+func newMCPSession() *mcpSession {
+	return &mcpSession{
+		services:   make(map[string]*comments.Service),
+		identities: make(map[string]resolver.Identity),
+	}
+}
+
+func (s *mcpSession) service(host string) *comments.Service {
+	if service, ok := s.services[host]; ok {
+		return service
+	}
+	service := comments.NewService(apiClientFactory(host))
+	s.services[host] = service
+	return service
+}
+
+// resolve resolves a pull request selector once per session. The current-branch
+// default is not cached because the checkout may change while the server runs.
+func (s *mcpSession) resolve(args prArguments) (resolver.Identity, error) {
+	selector := strings.TrimSpace(string(args.PR))
+	key := selector + "\x00" + args.Repo
+	if identity, ok := s.identities[key]; ok {
+		return identity, nil
+	}
+	identity, err := resolver.Resolve(selector, 0, args.Repo)
+	if err != nil {
+		return resolver.Identity{}, err
+	}
+	if selector != "" {
+		s.identities[key] = identity
+	}
+	return identity, nil
+}
+
+// prSelector accepts a pull request number or URL given as a JSON string or number.
+type prSelector string
+
+func (p *prSelector) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*p = prSelector(number.String())
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return errors.New("pr must be a number or URL")
+	}
+	*p = prSelector(text)
+	return nil
+}
+
+type prArguments struct {
+	PR   prSelector `json:"pr"`
+	Repo string     `json:"repo"`
+}
+
+// decodeArguments strictly decodes tool arguments so typos surface as errors.
+func decodeArguments(raw json.RawMessage, target interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func envelope(fields map[string]interface{}) map[string]interface{} {
+	fields["schema_version"] = outputSchemaVersion
+	return fields
+}
+
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func withPullRequestProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["pr"] = map[string]interface{}{
+		"type":        []string{"integer", "string"},
+		"description": "Pull request number or URL; defaults to the pull request of the current branch",
+	}
+	properties["repo"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Repository as owner/repo or host/owner/repo",
+	}
+	return properties
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+var hostnameProperty = stringProperty("GitHub host; defaults to the gh default host")
+
+func (s *mcpSession) tools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "list_threads",
+			Description: "List inline review threads and their comments on a pull request.",
+			InputSchema: objectSchema(nil, withPullRequestProperties(map[string]interface{}{
+				"unresolved_only":   map[string]interface{}{"type": "boolean", "description": "Only return unresolved threads"},
+				"exclude_minimized": map[string]interface{}{"type": "boolean", "description": "Omit hidden comments and threads left empty"},
+				"exclude_bots":      map[string]interface{}{"type": "boolean", "description": "Omit comments by bots and threads left empty"},
+				"author_association": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only keep comments whose author has one of these associations (OWNER, MEMBER, COLLABORATOR, CONTRIBUTOR, FIRST_TIME_CONTRIBUTOR, FIRST_TIMER, MANNEQUIN, NONE)",
+				},
+			})),
+			Handler: s.listThreads,
+		},
+		{
+			Name:        "review_status",
+			Description: "Summarize thread counts and the latest decision of each reviewer.",
+			InputSchema: objectSchema(nil, withPullRequestProperties(map[string]interface{}{})),
+			Handler:     s.reviewStatus,
+		},
+		{
+			Name:        "create_comment",
+			Description: "Open a new inline review thread on a line (or line range) of the diff.",
+			InputSchema: objectSchema([]string{"path", "line", "body"}, withPullRequestProperties(map[string]interface{}{
+				"path":       stringProperty("File path in the repository"),
+				"line":       map[string]interface{}{"type": "integer", "minimum": 1, "description": "Line the comment applies to (the last line of a range)"},
+				"body":       stringProperty("Comment body (Markdown)"),
+				"side":       map[string]interface{}{"enum": []string{"LEFT", "RIGHT"}, "description": "Diff side of line; defaults to RIGHT"},
+				"start_line": map[string]interface{}{"type": "integer", "minimum": 1, "description": "First line of a multi-line comment"},
+				"start_side": map[string]interface{}{"enum": []string{"LEFT", "RIGHT"}, "description": "Diff side of start_line"},
+			})),
+			Handler: s.createComment,
+		},
+		{
+			Name:        "reply",
+			Description: "Reply to an existing review thread.",
+			InputSchema: objectSchema([]string{"thread_id", "body"}, map[string]interface{}{
+				"thread_id": stringProperty("Review thread node ID (PRRT_...)"),
+				"body":      stringProperty("Reply body (Markdown)"),
+				"hostname":  hostnameProperty,
+			}),
+			Handler: s.reply,
+		},
+		{
+			Name:        "resolve_thread",
+			Description: "Mark a review thread as resolved.",
+			InputSchema: objectSchema([]string{"thread_id"}, map[string]interface{}{
+				"thread_id": stringProperty("Review thread node ID (PRRT_...)"),
+				"hostname":  hostnameProperty,
+			}),
+			Handler: s.resolveThread,
+		},
+		{
+			Name:        "unresolve_thread",
+			Description: "Reopen a resolved review thread.",
+			InputSchema: objectSchema([]string{"thread_id"}, map[string]interface{}{
+				"thread_id": stringProperty("Review thread node ID (PRRT_...)"),
+				"hostname":  hostnameProperty,
+			}),
+			Handler: s.unresolveThread,
+		},
+		{
+			Name:        "hide_comment",
+			Description: "Minimize a comment, like the web UI's Hide menu.",
+			InputSchema: objectSchema([]string{"comment_id", "reason"}, map[string]interface{}{
+				"comment_id": stringProperty("Comment node ID"),
+				"reason":     map[string]interface{}{"enum": []string{"outdated", "resolved", "duplicate", "off-topic", "spam", "abuse"}},
+				"hostname":   hostnameProperty,
+			}),
+			Handler: s.hideComment,
+		},
+		{
+			Name:        "unhide_comment",
+			Description: "Restore a previously hidden comment.",
+			InputSchema: objectSchema([]string{"comment_id"}, map[string]interface{}{
+				"comment_id": stringProperty("Comment node ID"),
+				"hostname":   hostnameProperty,
+			}),
+			Handler: s.unhideComment,
+		},
+	}
+}
+
+func (s *mcpSession) listThreads(raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		prArguments
+		UnresolvedOnly    bool     `json:"unresolved_only"`
+		ExcludeMinimized  bool     `json:"exclude_minimized"`
+		ExcludeBots       bool     `json:"exclude_bots"`
+		AuthorAssociation []string `json:"author_association"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	associations, err := comments.ParseAuthorAssociations(args.AuthorAssociation)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.resolve(args.prArguments)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, warnings, err := s.service(identity.Host).List(identity)
+	if err != nil {
+		return nil, err
+	}
+	if args.UnresolvedOnly {
+		threads = comments.FilterUnresolved(threads)
+	}
+	if args.ExcludeMinimized {
+		threads = comments.FilterMinimized(threads)
+	}
+	threads = comments.FilterThreadAuthors(threads, comments.AuthorFilter{ExcludeBots: args.ExcludeBots, Associations: associations})
+
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"threads":      threads,
+	}
+	if len(warnings) > 0 {
+		payload["warnings"] = warnings
+	}
+	return envelope(payload), nil
+}
+
+func (s *mcpSession) reviewStatus(raw json.RawMessage) (interface{}, error) {
+	var args prArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	identity, err := s.resolve(args)
+	if err != nil {
+		return nil, err
+	}
+
+	service := s.service(identity.Host)
+	threads, warnings, err := service.List(identity)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := service.ListReviews(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"status":       comments.Summarize(threads, reviews),
+	}
+	if len(warnings) > 0 {
+		payload["warnings"] = warnings
+	}
+	return envelope(payload), nil
+}
+
+func (s *mcpSession) createComment(raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		prArguments
+		Path      string  `json:"path"`
+		Line      int     `json:"line"`
+		Body      string  `json:"body"`
+		Side      string  `json:"side"`
+		StartLine *int    `json:"start_line"`
+		StartSide *string `json:"start_side"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Side == "" {
+		args.Side = "RIGHT"
+	}
+	identity, err := s.resolve(args.prArguments)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.service(identity.Host).Create(identity, comments.CreateInput{
+		Path:      args.Path,
+		Line:      args.Line,
+		Side:      args.Side,
+		StartLine: args.StartLine,
+		StartSide: args.StartSide,
+		Body:      args.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope(map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"comment":      created,
+	}), nil
+}
+
+type threadArguments struct {
+	ThreadID string `json:"thread_id"`
+	Hostname string `json:"hostname"`
+}
+
+func (s *mcpSession) reply(raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		threadArguments
+		Body string `json:"body"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	comment, err := s.service(args.Hostname).Reply(args.ThreadID, args.Body)
+	if err != nil {
+		return nil, err
+	}
+	return envelope(map[string]interface{}{
+		"thread_id": strings.TrimSpace(args.ThreadID),
+		"comment":   comment,
+	}), nil
+}
+
+func (s *mcpSession) resolveThread(raw json.RawMessage) (interface{}, error) {
+	var args threadArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	result, err := s.service(args.Hostname).Resolve(args.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	return envelope(map[string]interface{}{"thread": result}), nil
+}
+
+func (s *mcpSession) unresolveThread(raw json.RawMessage) (interface{}, error) {
+	var args threadArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	result, err := s.service(args.Hostname).Unresolve(args.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	return envelope(map[string]interface{}{"thread": result}), nil
+}
+
+type commentArguments struct {
+	CommentID string `json:"comment_id"`
+	Hostname  string `json:"hostname"`
+}
+
+func (s *mcpSession) hideComment(raw json.RawMessage) (interface{}, error) {
+	var args struct {
+		commentArguments
+		Reason string `json:"reason"`
+	}
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	result, err := s.service(args.Hostname).Minimize(args.CommentID, args.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return envelope(map[string]interface{}{"comments": []comments.MinimizeResult{result}}), nil
+}
+
+func (s *mcpSession) unhideComment(raw json.RawMessage) (interface{}, error) {
+	var args commentArguments
+	if err := decodeArguments(raw, &args); err != nil {
+		return nil, err
+	}
+	result, err := s.service(args.Hostname).Unminimize(args.CommentID)
+	if err != nil {
+		return nil, err
+	}
+	return envelope(map[string]interface{}{"comments": []comments.MinimizeResult{result}}), nil
+}
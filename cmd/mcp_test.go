@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+)
+
+func TestMCPCreateCommentDefaultsToRightSide(t *testing.T) {
+	useBackend(t, newTestBackend(testCommit))
+	session := newMCPSession()
+
+	value, err := session.createComment(json.RawMessage(`{"pr": 7, "repo": "acme/widgets", "path": "main.go", "line": 2, "body": "Consider log instead"}`))
+	if err != nil {
+		t.Fatalf("create_comment: %v", err)
+	}
+	created := value.(map[string]interface{})["comment"].(comments.CreateResult)
+	if created.RequestedSide != "RIGHT" {
+		t.Fatalf("requested_side = %q, want RIGHT", created.RequestedSide)
+	}
+}
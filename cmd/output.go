@@ -2,14 +2,279 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"text/template"
 
+	"github.com/itchyny/gojq"
 	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const (
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatMarkdown = "markdown"
 )
 
+func pullRequestPayload(identity resolver.Identity) map[string]interface{} {
+	return map[string]interface{}{
+		"owner":  identity.Owner,
+		"repo":   identity.Repo,
+		"host":   identity.Host,
+		"number": identity.Number,
+		"url":    identity.URL,
+	}
+}
+
+// includeMeta is set by the root --meta flag.
+var includeMeta bool
+
+// outputSettings is populated from the root --json, --jq and --template flags
+// and shapes every JSON value a command prints, mirroring `gh`.
+var outputSettings struct {
+	Fields   string
+	JQ       string
+	Template string
+
+	fields   map[string]bool
+	query    *gojq.Code
+	template *template.Template
+}
+
+// prepareOutput validates the output shaping flags before any API call is made.
+func prepareOutput() error {
+	outputSettings.fields, outputSettings.query, outputSettings.template = nil, nil, nil
+	if outputSettings.JQ != "" && outputSettings.Template != "" {
+		return errors.New("--jq and --template cannot be combined")
+	}
+
+	if raw := strings.TrimSpace(outputSettings.Fields); raw != "" {
+		outputSettings.fields = make(map[string]bool)
+		for _, field := range strings.Split(raw, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				outputSettings.fields[field] = true
+			}
+		}
+	}
+
+	if outputSettings.JQ != "" {
+		parsed, err := gojq.Parse(outputSettings.JQ)
+		if err != nil {
+			return fmt.Errorf("parse --jq expression: %w", err)
+		}
+		code, err := gojq.Compile(parsed)
+		if err != nil {
+			return fmt.Errorf("compile --jq expression: %w", err)
+		}
+		outputSettings.query = code
+	}
+
+	if outputSettings.Template != "" {
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(outputSettings.Template)
+		if err != nil {
+			return fmt.Errorf("parse --template: %w", err)
+		}
+		outputSettings.template = tmpl
+	}
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, items []interface{}) string {
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, sep)
+	},
+	"truncate": func(length int, text string) string {
+		runes := []rune(text)
+		if len(runes) <= length {
+			return text
+		}
+		return string(runes[:length])
+	},
+}
+
+// encodeJSON writes a command's JSON envelope stamped with schema_version, plus
+// the API usage block when --meta is set. The envelope is stamped before --json
+// field selection, so schema_version and meta are only kept when selected.
+// Payloads that are not envelopes (such as SARIF logs) are written as they are.
 func encodeJSON(cmd *cobra.Command, payload interface{}) error {
-	enc := json.NewEncoder(cmd.OutOrStdout())
+	if envelope, ok := payload.(map[string]interface{}); ok {
+		stamped := make(map[string]interface{}, len(envelope)+2)
+		for key, value := range envelope {
+			stamped[key] = value
+		}
+		stamped["schema_version"] = outputSchemaVersion
+		if includeMeta {
+			stamped["meta"] = apiMeter.Usage()
+		}
+		payload = stamped
+	}
+	shaped, err := shapeOutput(payload)
+	if err != nil {
+		return err
+	}
+	return writeOutput(cmd, shaped)
+}
+
+// printWarnings reports warnings that have no place in a command's output on
+// stderr, one per line.
+func printWarnings(cmd *cobra.Command, warnings []comments.Warning) {
+	for _, w := range warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", w)
+	}
+}
+
+// encodeJSONLine writes one compact JSON value without an envelope, as used for
+// NDJSON records.
+func encodeJSONLine(cmd *cobra.Command, payload interface{}) error {
+	shaped, err := shapeOutput(payload)
+	if err != nil {
+		return err
+	}
+	return writeOutput(cmd, shaped)
+}
+
+// shapingRequested reports whether --json, --jq or --template was given.
+func shapingRequested() bool {
+	return outputSettings.fields != nil || outputSettings.query != nil || outputSettings.template != nil
+}
+
+// shapeOutput applies --json field selection. Without it the payload is returned
+// unchanged; with it the payload is converted to generic JSON values first.
+func shapeOutput(payload interface{}) (interface{}, error) {
+	if !shapingRequested() {
+		return payload, nil
+	}
+	value, err := genericJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	if outputSettings.fields != nil {
+		value, _ = selectFields(value, outputSettings.fields)
+	}
+	return value, nil
+}
+
+// writeOutput prints a value through --jq or --template, or as compact JSON.
+// Values reaching --jq or --template have already been made generic by shapeOutput.
+func writeOutput(cmd *cobra.Command, value interface{}) error {
+	out := cmd.OutOrStdout()
+	switch {
+	case outputSettings.query != nil:
+		return runJQ(out, outputSettings.query, value)
+	case outputSettings.template != nil:
+		if err := outputSettings.template.Execute(out, value); err != nil {
+			return fmt.Errorf("execute --template: %w", err)
+		}
+		return nil
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return nil
+}
+
+// runJQ prints each result of the query on its own line; strings are printed raw
+// like `gh --jq`.
+func runJQ(out io.Writer, code *gojq.Code, value interface{}) error {
+	iter := code.Run(value)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := result.(error); isErr {
+			return fmt.Errorf("evaluate --jq expression: %w", err)
+		}
+		if text, isString := result.(string); isString {
+			if _, err := fmt.Fprintln(out, text); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := gojq.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("encode --jq result: %w", err)
+		}
+		if _, err := fmt.Fprintln(out, string(data)); err != nil {
+			return err
+		}
+	}
+}
+
+// genericJSON round-trips a payload into maps, slices and scalars.
+func genericJSON(payload interface{}) (interface{}, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	return value, nil
+}
+
+// selectFields keeps the requested fields at the outermost level where any of
+// them appear: an object with a matching key is reduced to the matching keys
+// (their values kept whole), otherwise its children are searched and only those
+// containing a match are kept. Arrays are filtered element-wise, and empty
+// arrays are kept so collections keep a stable shape. The boolean reports
+// whether anything matched.
+func selectFields(value interface{}, fields map[string]bool) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		selected := make(map[string]interface{})
+		for key, child := range v {
+			if fields[key] {
+				selected[key] = child
+			}
+		}
+		if len(selected) > 0 {
+			return selected, true
+		}
+		for key, child := range v {
+			if projected, ok := selectFields(child, fields); ok {
+				selected[key] = projected
+			}
+		}
+		return selected, len(selected) > 0
+	case []interface{}:
+		projected := make([]interface{}, 0, len(v))
+		matched := len(v) == 0
+		for _, item := range v {
+			child, ok := selectFields(item, fields)
+			if ok {
+				matched = true
+				projected = append(projected, child)
+			}
+		}
+		return projected, matched
+	}
+	return value, false
+}
+
+// writeIndentedJSON writes a human-diffable JSON document, used for files meant
+// to be committed or archived.
+func writeIndentedJSON(w io.Writer, payload interface{}) error {
+	enc := json.NewEncoder(w)
 	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
 	if err := enc.Encode(payload); err != nil {
 		return fmt.Errorf("encode json: %w", err)
 	}
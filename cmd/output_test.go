@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func listOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	stdout, stderr, err := runCLI(t, newTestBackend(testCommit), append([]string{"list", "7", "-R", "acme/widgets"}, args...)...)
+	if err != nil {
+		t.Fatalf("list %v: %v\nstderr: %s", args, err, stderr)
+	}
+	return stdout
+}
+
+func decodeObject(t *testing.T, output string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("decode %q: %v", output, err)
+	}
+	return doc
+}
+
+func TestJSONSelectsOnlyRequestedFields(t *testing.T) {
+	doc := decodeObject(t, listOutput(t, "--json", "id,path"))
+	want := map[string]interface{}{
+		"threads": []interface{}{map[string]interface{}{"id": testThread, "path": "main.go"}},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("--json id,path = %v, want %v", doc, want)
+	}
+
+	doc = decodeObject(t, listOutput(t, "--json", "schema_version,threads"))
+	if doc["schema_version"] != float64(outputSchemaVersion) || doc["threads"] == nil || len(doc) != 2 {
+		t.Fatalf("--json schema_version,threads = %v", doc)
+	}
+
+	doc = decodeObject(t, listOutput(t, "--meta", "--json", "threads"))
+	if _, ok := doc["meta"]; ok {
+		t.Fatalf("--json threads kept meta: %v", doc)
+	}
+	doc = decodeObject(t, listOutput(t, "--meta"))
+	if _, ok := doc["meta"]; !ok {
+		t.Fatalf("--meta without --json dropped meta: %v", doc)
+	}
+}
+
+func TestJSONSelectsFieldsInNestedArrays(t *testing.T) {
+	doc := decodeObject(t, listOutput(t, "--json", "body"))
+	want := map[string]interface{}{
+		"threads": []interface{}{map[string]interface{}{
+			"comments": []interface{}{map[string]interface{}{"body": "Why fmt?"}},
+		}},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("--json body = %v, want %v", doc, want)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	fields := map[string]bool{"id": true, "login": true}
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{name: "outermost match wins", value: `{"id": 1, "author": {"login": "bob"}}`, want: `{"id": 1}`, ok: true},
+		{name: "descends into objects", value: `{"author": {"login": "bob", "type": "User"}, "body": "x"}`, want: `{"author": {"login": "bob"}}`, ok: true},
+		{name: "filters array elements", value: `[{"id": 1, "x": 2}, {"x": 3}, {"id": 4}]`, want: `[{"id": 1}, {"id": 4}]`, ok: true},
+		{name: "keeps empty arrays", value: `{"threads": [], "title": "t"}`, want: `{"threads": []}`, ok: true},
+		{name: "no match", value: `{"title": "t"}`, want: `{}`, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value, want interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := selectFields(value, fields)
+			if ok != tt.ok || !reflect.DeepEqual(got, want) {
+				t.Fatalf("selectFields = %v, %v; want %v, %v", got, ok, want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestJQPrintsStringsRaw(t *testing.T) {
+	if out := listOutput(t, "--jq", ".threads[].path"); out != "main.go\n" {
+		t.Fatalf("--jq string = %q, want the raw path", out)
+	}
+	if out := listOutput(t, "--jq", ".threads[] | {id, line}"); strings.TrimSpace(out) != `{"id":"`+testThread+`","line":2}` {
+		t.Fatalf("--jq object = %q", out)
+	}
+	if out := listOutput(t, "--json", "path", "--jq", ".threads[0] | keys"); strings.TrimSpace(out) != `["path"]` {
+		t.Fatalf("--jq after --json = %q, want only the selected field", out)
+	}
+	if out := listOutput(t, "--jq", ".schema_version"); strings.TrimSpace(out) != "2" {
+		t.Fatalf("--jq .schema_version = %q, want 2", out)
+	}
+}
+
+func TestTemplateRendersOutput(t *testing.T) {
+	out := listOutput(t, "--template", `{{range .threads}}{{.path}}:{{.line}} {{truncate 3 (index .comments 0).body}}{{"\n"}}{{end}}`)
+	if out != "main.go:2 Why\n" {
+		t.Fatalf("--template = %q", out)
+	}
+	out = listOutput(t, "--json", "id", "--template", `{{json .}}`)
+	if out != `{"threads":[{"id":"`+testThread+`"}]}` {
+		t.Fatalf("--template json = %q", out)
+	}
+}
+
+func TestJQAndTemplateAreExclusive(t *testing.T) {
+	if _, _, err := runCLI(t, newTestBackend(testCommit), "list", "7", "-R", "acme/widgets", "--jq", ".", "--template", "{{.}}"); err == nil {
+		t.Fatal("expected --jq and --template to be rejected together")
+	}
+}
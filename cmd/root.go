@@ -9,8 +9,15 @@ import (
 
 // Execute sets up the root command tree and executes it.
 func Execute() error {
+	if err := configureBackend(); err != nil {
+		return err
+	}
 	root := newRootCommand()
-	return root.Execute()
+	err := root.Execute()
+	if closeErr := closeTracing(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 func newRootCommand() *cobra.Command {
@@ -19,10 +26,41 @@ func newRootCommand() *cobra.Command {
 		Short:         "List and create inline pull request comments",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := prepareOutput(); err != nil {
+				return err
+			}
+			return configureTracing()
+		},
 	}
 
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&cacheSettings.Enabled, "cache", false, "Cache read requests on disk (also enabled by GH_PR_COMMENTS_CACHE=1)")
+	flags.BoolVar(&cacheSettings.Disabled, "no-cache", false, "Bypass the disk cache")
+	flags.DurationVar(&cacheSettings.TTL, "cache-ttl", cacheSettings.TTL, "How long cached responses are served without revalidation")
+	flags.StringVar(&outputSettings.Fields, "json", "", "Output only the given comma-separated JSON `fields`")
+	flags.StringVarP(&outputSettings.JQ, "jq", "q", "", "Filter JSON output using a jq `expression`")
+	flags.StringVarP(&outputSettings.Template, "template", "t", "", "Format JSON output using a Go `template`")
+	flags.BoolVar(&includeMeta, "meta", false, "Add a meta block with API calls, cost, remaining quota and elapsed time to JSON output")
+	flags.BoolVar(&debugSettings.Enabled, "debug", false, "Log every gh invocation to stderr (also enabled by GH_PR_COMMENTS_DEBUG=1)")
+	flags.StringVar(&debugSettings.TraceFile, "trace-file", "", "Write a JSON line per gh invocation to this file")
+
 	cmd.AddCommand(newListCommand())
 	cmd.AddCommand(newCreateCommand())
+	cmd.AddCommand(newHideCommand())
+	cmd.AddCommand(newUnhideCommand())
+	cmd.AddCommand(newStatusCommand())
+	cmd.AddCommand(newSearchCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newImportCommand())
+	cmd.AddCommand(newCacheCommand())
+	cmd.AddCommand(newSchemaCommand())
+	cmd.AddCommand(newAddressCommand())
+	cmd.AddCommand(newSyncTrailersCommand())
+	cmd.AddCommand(newThreadDiffCommand())
+	cmd.AddCommand(newExecCommand())
+	cmd.AddCommand(newMCPCommand())
+	cmd.AddCommand(newBrowseCommand())
 
 	return cmd
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+)
+
+// outputSchemaVersion is stamped on every JSON envelope as schema_version. Bump
+// it, and the schemas under schemas/, whenever a field is renamed or removed.
+const outputSchemaVersion = 2
+
+//go:embed schemas/*.json
+var outputSchemas embed.FS
+
+// schemaFiles maps each command to the schema describing its JSON output.
+var schemaFiles = map[string]string{
+	"address":       "address.schema.json",
+	"cache":         "cache.schema.json",
+	"create":        "create.schema.json",
+	"exec":          "exec.schema.json",
+	"hide":          "hide.schema.json",
+	"unhide":        "hide.schema.json",
+	"import":        "import.schema.json",
+	"list":          "list.schema.json",
+	"search":        "search.schema.json",
+	"status":        "status.schema.json",
+	"sync-trailers": "sync-trailers.schema.json",
+	"thread-diff":   "thread-diff.schema.json",
+}
+
+func newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [<command>]",
+		Short: "Print the JSON Schema of a command's output",
+		Long: "Print the JSON Schema (draft 2020-12) describing a command's JSON output.\n" +
+			"Without arguments, lists the commands that have a schema.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), strings.Join(schemaCommands(), "\n"))
+				return err
+			}
+			schema, err := outputSchema(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+}
+
+func schemaCommands() []string {
+	names := []string{"export"}
+	for name := range schemaFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// outputSchema returns a self-contained schema for command, with the shared
+// definitions from schemas/defs.json inlined under $defs.
+func outputSchema(command string) ([]byte, error) {
+	if command == "export" {
+		return comments.ArchiveSchema(), nil
+	}
+	file, ok := schemaFiles[command]
+	if !ok {
+		return nil, fmt.Errorf("no schema for command %q: must be one of %s", command, strings.Join(schemaCommands(), ", "))
+	}
+
+	var schema map[string]interface{}
+	if err := readSchemaFile(file, &schema); err != nil {
+		return nil, err
+	}
+	var defs map[string]interface{}
+	if err := readSchemaFile("defs.json", &defs); err != nil {
+		return nil, err
+	}
+	if own, ok := schema["$defs"].(map[string]interface{}); ok {
+		for name, def := range own {
+			defs[name] = def
+		}
+	}
+	schema["$defs"] = defs
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func readSchemaFile(name string, target interface{}) error {
+	data, err := outputSchemas.ReadFile("schemas/" + name)
+	if err != nil {
+		return fmt.Errorf("read schema %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("parse schema %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schemaValidator checks documents against the subset of JSON Schema 2020-12
+// used by the embedded schemas: type, $ref into $defs, properties, required,
+// additionalProperties, items, enum, const, minimum, maximum, allOf, anyOf and
+// oneOf. Annotations such as title and format are ignored.
+type schemaValidator struct {
+	root map[string]interface{}
+}
+
+func (v schemaValidator) validate(schema interface{}, value interface{}, at string) []string {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return []string{at + ": not allowed"}
+		}
+		return nil
+	case map[string]interface{}:
+		return v.validateObject(s, value, at)
+	}
+	return []string{fmt.Sprintf("%s: invalid schema %T", at, schema)}
+}
+
+func (v schemaValidator) validateObject(schema map[string]interface{}, value interface{}, at string) []string {
+	var problems []string
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", at, err)}
+		}
+		problems = append(problems, v.validate(target, value, at)...)
+	}
+
+	if kind, ok := schema["type"].(string); ok && !hasType(value, kind) {
+		return append(problems, fmt.Sprintf("%s: got %s, want %s", at, describe(value), kind))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", at, value, enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		problems = append(problems, fmt.Sprintf("%s: %v is not %v", at, value, constant))
+	}
+	if number, ok := value.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && number < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is below %v", at, number, min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && number > max {
+			problems = append(problems, fmt.Sprintf("%s: %v is above %v", at, number, max))
+		}
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		problems = append(problems, v.validateProperties(schema, object, at)...)
+	}
+	if array, ok := value.([]interface{}); ok {
+		if items, ok := schema["items"]; ok {
+			for i, item := range array {
+				problems = append(problems, v.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			problems = append(problems, v.validate(sub, value, at)...)
+		}
+	}
+	if any, ok := schema["anyOf"].([]interface{}); ok && v.matching(any, value, at) == 0 {
+		problems = append(problems, at+": matches none of anyOf")
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		if n := v.matching(one, value, at); n != 1 {
+			problems = append(problems, fmt.Sprintf("%s: matches %d of oneOf, want exactly 1", at, n))
+		}
+	}
+	return problems
+}
+
+func (v schemaValidator) validateProperties(schema map[string]interface{}, object map[string]interface{}, at string) []string {
+	var problems []string
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := object[name.(string)]; !present {
+				problems = append(problems, fmt.Sprintf("%s: missing required %q", at, name))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sub, ok := properties[name]; ok {
+			problems = append(problems, v.validate(sub, object[name], at+"."+name)...)
+		} else if hasAdditional {
+			problems = append(problems, v.validate(additional, object[name], at+"."+name)...)
+		}
+	}
+	return problems
+}
+
+func (v schemaValidator) matching(schemas []interface{}, value interface{}, at string) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(v.validate(sub, value, at)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v schemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node interface{} = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func hasType(value interface{}, kind string) bool {
+	switch kind {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func describe(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// assertMatchesSchema validates a command's JSON output against its embedded schema.
+func assertMatchesSchema(t *testing.T, command string, output []byte) map[string]interface{} {
+	t.Helper()
+	data, err := outputSchema(command)
+	if err != nil {
+		t.Fatalf("schema for %s: %v", command, err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("parse schema for %s: %v", command, err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(output, &document); err != nil {
+		t.Fatalf("%s output is not a JSON object: %v\n%s", command, err, output)
+	}
+	if problems := (schemaValidator{root: schema}).validate(schema, document, "$"); len(problems) > 0 {
+		t.Fatalf("%s output does not match its schema:\n  %s\n%s", command, strings.Join(problems, "\n  "), output)
+	}
+	return document
+}
+
+func TestSchemaRefsResolve(t *testing.T) {
+	for _, command := range schemaCommands() {
+		data, err := outputSchema(command)
+		if err != nil {
+			t.Fatalf("schema for %s: %v", command, err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("parse schema for %s: %v", command, err)
+		}
+		v := schemaValidator{root: schema}
+		var walk func(node interface{})
+		walk = func(node interface{}) {
+			switch n := node.(type) {
+			case map[string]interface{}:
+				if ref, ok := n["$ref"].(string); ok {
+					if _, err := v.resolve(ref); err != nil {
+						t.Errorf("%s: %v", command, err)
+					}
+				}
+				for _, child := range n {
+					walk(child)
+				}
+			case []interface{}:
+				for _, child := range n {
+					walk(child)
+				}
+			}
+		}
+		walk(schema)
+	}
+}
+
+func TestSchemaValidatorRejectsMismatches(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []interface{}{"count"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "integer", "minimum": float64(0)},
+			"kind":  map[string]interface{}{"enum": []interface{}{"a", "b"}},
+		},
+	}
+	v := schemaValidator{root: schema}
+	for _, doc := range []string{`{}`, `{"count": -1}`, `{"count": 1.5}`, `{"count": 1, "kind": "c"}`, `{"count": 1, "extra": true}`} {
+		var value interface{}
+		if err := json.Unmarshal([]byte(doc), &value); err != nil {
+			t.Fatal(err)
+		}
+		if problems := v.validate(schema, value, "$"); len(problems) == 0 {
+			t.Errorf("%s: expected a validation error", doc)
+		}
+	}
+}
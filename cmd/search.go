@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+type searchOptions struct {
+	Repo        string
+	State       string
+	Author      string
+	Reviewer    string
+	Labels      []string
+	Limit       int
+	Concurrency int
+	Unresolved  bool
+	Format      string
+}
+
+func newSearchCommand() *cobra.Command {
+	opts := &searchOptions{State: "open", Limit: 100, Concurrency: 4, Format: formatJSON}
+
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "List review threads across many pull requests in a repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSearch(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().StringVar(&opts.State, "state", opts.State, "Pull request state: open, closed, merged, or all")
+	cmd.Flags().StringVar(&opts.Author, "author", "", "Filter by pull request author")
+	cmd.Flags().StringVar(&opts.Reviewer, "reviewer", "", "Filter by a user who reviewed the pull request")
+	cmd.Flags().StringSliceVar(&opts.Labels, "label", nil, "Filter by label (repeatable)")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "L", opts.Limit, "Maximum number of pull requests to fetch")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of pull requests fetched in parallel")
+	cmd.Flags().BoolVar(&opts.Unresolved, "unresolved", false, "Only include unresolved threads")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "Output format: json or ndjson")
+
+	return cmd
+}
+
+func runSearch(cmd *cobra.Command, opts *searchOptions) error {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format != formatJSON && format != formatNDJSON {
+		return fmt.Errorf("invalid format %q: must be %s or %s", opts.Format, formatJSON, formatNDJSON)
+	}
+	if opts.Concurrency <= 0 {
+		return errors.New("--concurrency must be a positive integer")
+	}
+
+	repo, err := resolver.ResolveRepository(opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(repo.Host))
+	prs, err := service.Search(repo, comments.SearchCriteria{
+		State:    opts.State,
+		Author:   opts.Author,
+		Reviewer: opts.Reviewer,
+		Labels:   opts.Labels,
+		Limit:    opts.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	results := service.ListMany(prs, opts.Concurrency)
+	entries := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		threads := result.Threads
+		if opts.Unresolved {
+			threads = comments.FilterUnresolved(threads)
+		}
+
+		pullRequest := pullRequestPayload(result.PullRequest.Identity)
+		pullRequest["title"] = result.PullRequest.Title
+		pullRequest["state"] = result.PullRequest.State
+		pullRequest["author"] = result.PullRequest.Author
+
+		entry := map[string]interface{}{
+			"pull_request": pullRequest,
+			"threads":      threads,
+		}
+		if len(result.Warnings) > 0 {
+			entry["warnings"] = result.Warnings
+		}
+		if result.Err != nil {
+			entry["threads"] = []comments.Thread{}
+			entry["error"] = result.Err.Error()
+		}
+
+		if format == formatNDJSON {
+			if err := encodeJSONLine(cmd, entry); err != nil {
+				return err
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if format == formatNDJSON {
+		if includeMeta {
+			return encodeJSONLine(cmd, map[string]interface{}{"meta": apiMeter.Usage()})
+		}
+		return nil
+	}
+	return encodeJSON(cmd, map[string]interface{}{
+		"repository": map[string]interface{}{
+			"owner": repo.Owner,
+			"repo":  repo.Repo,
+			"host":  repo.Host,
+		},
+		"pull_requests": entries,
+	})
+}
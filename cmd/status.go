@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+type statusOptions struct {
+	Repo     string
+	Pull     int
+	Selector string
+
+	FailOnUnresolved bool
+	AllowPartial     bool
+}
+
+func newStatusCommand() *cobra.Command {
+	opts := &statusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status [<number> | <url>]",
+		Short: "Summarize review thread and reviewer state for a pull request",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runStatus(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().BoolVar(&opts.FailOnUnresolved, "fail-on-unresolved", false, "Exit with a non-zero status when unresolved threads remain")
+	cmd.Flags().BoolVar(&opts.AllowPartial, "allow-partial", false, "With --fail-on-unresolved, pass on partial data instead of failing when GitHub withheld some threads")
+
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, opts *statusOptions) error {
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+
+	service := comments.NewService(apiClientFactory(identity.Host))
+	threads, warnings, err := service.List(identity)
+	if err != nil {
+		return err
+	}
+	reviews, err := service.ListReviews(identity)
+	if err != nil {
+		return err
+	}
+
+	summary := comments.Summarize(threads, reviews)
+	payload := map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"status":       summary,
+	}
+	if len(warnings) > 0 {
+		payload["warnings"] = warnings
+	}
+	if err := encodeJSON(cmd, payload); err != nil {
+		return err
+	}
+
+	if !opts.FailOnUnresolved {
+		return nil
+	}
+	if summary.Threads.Unresolved > 0 {
+		return fmt.Errorf("%d unresolved review threads from %d reviewers", summary.Threads.Unresolved, summary.UnresolvedAuthors)
+	}
+	// Withheld threads may be unresolved, so a gate must not pass on partial data.
+	if len(warnings) > 0 && !opts.AllowPartial {
+		return fmt.Errorf("review threads are incomplete (%d warnings), so unresolved threads may be missing; pass --allow-partial to accept partial data", len(warnings))
+	}
+	return nil
+}
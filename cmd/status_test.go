@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusFailOnUnresolved(t *testing.T) {
+	stdout, _, err := runCLI(t, newTestBackend(testCommit), "status", "7", "-R", "acme/widgets", "--fail-on-unresolved")
+	if err == nil || !strings.Contains(err.Error(), "1 unresolved review threads from 1 reviewers") {
+		t.Fatalf("err = %v, want the unresolved thread to fail the gate", err)
+	}
+	doc := assertMatchesSchema(t, "status", []byte(stdout))
+	status := doc["status"].(map[string]interface{})
+	if threads := status["threads"].(map[string]interface{}); threads["unresolved"] != float64(1) {
+		t.Fatalf("threads = %v, want one unresolved", threads)
+	}
+
+	backend := newTestBackend(testCommit)
+	backend.PullRequests[0].Threads[0].IsResolved = true
+	if _, _, err := runCLI(t, backend, "status", "7", "-R", "acme/widgets", "--fail-on-unresolved"); err != nil {
+		t.Fatalf("all threads resolved: %v", err)
+	}
+
+	if _, _, err := runCLI(t, newTestBackend(testCommit), "status", "7", "-R", "acme/widgets"); err != nil {
+		t.Fatalf("without --fail-on-unresolved: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/git"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const (
+	defaultTrailerKey = "Addresses-Review"
+	trailerStateFile  = "gh-pr-comments/sync-trailers.json"
+)
+
+// Outcomes of one trailer reference.
+const (
+	trailerAddressed        = "addressed"
+	trailerPlanned          = "planned"
+	trailerAlreadyProcessed = "already_processed"
+	trailerNotPushed        = "not_pushed"
+	trailerNotFound         = "not_found"
+	trailerFailed           = "failed"
+)
+
+// discussionRE extracts the review comment number from a comment URL, which
+// GitHub writes as #discussion_r<n> on the conversation tab and #r<n> on the files tab.
+var discussionRE = regexp.MustCompile(`#(?:discussion_)?r([0-9]+)$`)
+
+type syncTrailersOptions struct {
+	Repo     string
+	Pull     int
+	Selector string
+	Range    string
+	Trailer  string
+	DryRun   bool
+}
+
+func newSyncTrailersCommand() *cobra.Command {
+	opts := &syncTrailersOptions{Trailer: defaultTrailerKey}
+
+	cmd := &cobra.Command{
+		Use:   "sync-trailers [<number> | <url>]",
+		Short: "Address threads referenced by commit message trailers",
+		Long: "Scan local commits for trailers such as\n\n" +
+			"  Addresses-Review: PRRT_kwDOAbc123\n" +
+			"  Addresses-Review: https://github.com/owner/repo/pull/7#discussion_r123\n\n" +
+			"and, for each referenced thread, reply with a link to the commit and resolve it.\n" +
+			"Values may be thread IDs, comment IDs or comment URLs, separated by commas.\n" +
+			"Processed commit/thread pairs are recorded in the git directory, so reruns skip them.\n" +
+			"Commits not yet pushed to the pull request branch are skipped and retried next time.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Selector = args[0]
+			}
+			return runSyncTrailers(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Repo, "repo", "R", "", "Repository in 'owner/repo' format")
+	cmd.Flags().IntVar(&opts.Pull, "pr", 0, "Pull request number")
+	cmd.Flags().StringVar(&opts.Range, "range", "", "Commits to scan (defaults to origin/<base branch>..HEAD)")
+	cmd.Flags().StringVar(&opts.Trailer, "trailer", opts.Trailer, "Trailer key that references review threads")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Report what would be addressed without posting")
+
+	return cmd
+}
+
+type trailerResult struct {
+	Commit     string            `json:"commit"`
+	Reference  string            `json:"reference"`
+	ThreadID   string            `json:"thread_id,omitempty"`
+	Status     string            `json:"status"`
+	Comment    *comments.Comment `json:"comment,omitempty"`
+	IsResolved bool              `json:"is_resolved,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func runSyncTrailers(cmd *cobra.Command, opts *syncTrailersOptions) error {
+	key := strings.TrimSpace(opts.Trailer)
+	if key == "" || strings.ContainsAny(key, ",:) \t") {
+		return fmt.Errorf("invalid trailer key %q", opts.Trailer)
+	}
+
+	identity, err := resolver.Resolve(opts.Selector, opts.Pull, opts.Repo)
+	if err != nil {
+		return err
+	}
+	service := comments.NewService(apiClientFactory(identity.Host))
+
+	branch, err := service.Branch(identity)
+	if err != nil {
+		return err
+	}
+	revRange := strings.TrimSpace(opts.Range)
+	if revRange == "" {
+		revRange = "origin/" + branch.BaseRef + "..HEAD"
+	}
+
+	commits, err := git.Trailers(revRange, key)
+	if err != nil {
+		return fmt.Errorf("scan commits in %s: %w", revRange, err)
+	}
+
+	threads, warnings, err := service.List(identity)
+	if err != nil {
+		return err
+	}
+	printWarnings(cmd, warnings)
+
+	statePath, err := git.Path(trailerStateFile)
+	if err != nil {
+		return fmt.Errorf("locate git directory: %w", err)
+	}
+	state, err := loadTrailerState(statePath)
+	if err != nil {
+		return err
+	}
+	prKey := fmt.Sprintf("%s/%s/%s#%d", identity.Host, identity.Owner, identity.Repo, identity.Number)
+	processed := make(map[string]bool)
+	for _, pair := range state[prKey] {
+		processed[pair] = true
+	}
+
+	results := make([]trailerResult, 0)
+	failed, changed := 0, false
+	for _, c := range commits {
+		for _, reference := range c.Values {
+			result := trailerResult{Commit: c.SHA, Reference: reference}
+			threadID, ok := findReferencedThread(threads, reference)
+			if !ok {
+				result.Status = trailerNotFound
+				result.Error = fmt.Sprintf("no thread on pull request #%d matches %q", identity.Number, reference)
+				results = append(results, result)
+				continue
+			}
+			result.ThreadID = threadID
+
+			pair := c.SHA + " " + threadID
+			commit, pushed, findErr := branch.Find(c.SHA)
+			switch {
+			case processed[pair]:
+				result.Status = trailerAlreadyProcessed
+			case findErr != nil:
+				result.Status = trailerFailed
+				result.Error = findErr.Error()
+				failed++
+			case !pushed:
+				result.Status = trailerNotPushed
+			case opts.DryRun:
+				result.Status = trailerPlanned
+			default:
+				addressed := service.Address(threadID, commit, "")
+				result.Comment = addressed.Comment
+				result.IsResolved = addressed.IsResolved
+				result.Status = trailerAddressed
+				if addressed.Error != "" {
+					result.Status = trailerFailed
+					result.Error = addressed.Error
+					failed++
+				}
+				// Once the reply is posted the pair is done; retrying would post it again.
+				if addressed.Comment != nil {
+					state[prKey] = append(state[prKey], pair)
+					changed = true
+				}
+				processed[pair] = true
+			}
+			results = append(results, result)
+		}
+	}
+
+	if changed {
+		if err := saveTrailerState(statePath, state); err != nil {
+			return err
+		}
+	}
+
+	if err := encodeJSON(cmd, map[string]interface{}{
+		"pull_request": pullRequestPayload(identity),
+		"range":        revRange,
+		"dry_run":      opts.DryRun,
+		"results":      results,
+	}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d referenced threads could not be addressed", failed)
+	}
+	return nil
+}
+
+// findReferencedThread maps a trailer value (thread ID, comment ID or comment
+// URL) to the ID of the thread it refers to.
+func findReferencedThread(threads []comments.Thread, reference string) (string, bool) {
+	reference = strings.TrimSpace(reference)
+	number := ""
+	if m := discussionRE.FindStringSubmatch(reference); m != nil {
+		number = m[1]
+	}
+	for _, thread := range threads {
+		if thread.ID == reference {
+			return thread.ID, true
+		}
+		for _, c := range thread.Comments {
+			if c.ID == reference || c.URL == reference {
+				return thread.ID, true
+			}
+			if number == "" {
+				continue
+			}
+			if m := discussionRE.FindStringSubmatch(c.URL); m != nil && m[1] == number {
+				return thread.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// trailerState records processed "<sha> <thread-id>" pairs per pull request.
+type trailerState map[string][]string
+
+func loadTrailerState(path string) (trailerState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trailerState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	state := trailerState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func saveTrailerState(path string, state trailerState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode sync state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+)
+
+const secondThreadURL = "https://github.com/acme/widgets/pull/7#discussion_r4242"
+
+// commitTrailers adds an empty commit with message to the working directory's
+// repository and returns its SHA.
+func commitTrailers(t *testing.T, message string) string {
+	t.Helper()
+	runGitIn(t, ".", "commit", "--quiet", "--allow-empty", "-m", message)
+	return runGitIn(t, ".", "rev-parse", "HEAD")
+}
+
+// pushCommit makes a local commit part of the pull request branch.
+func pushCommit(backend *ghfake.Backend, sha string) {
+	pr := backend.PullRequests[0]
+	pr.Commits = append(pr.Commits, ghfake.Commit{SHA: sha, Headline: "Follow-up", Author: "alice"})
+	pr.HeadSHA = sha
+}
+
+// syncTrailers runs sync-trailers over the commits after base and returns the
+// schema-checked output with the command's error.
+func syncTrailers(t *testing.T, backend *ghfake.Backend, base string, args ...string) ([]map[string]interface{}, error) {
+	t.Helper()
+	args = append([]string{"sync-trailers", "7", "-R", "acme/widgets", "--range", base + "..HEAD"}, args...)
+	stdout, _, err := runCLI(t, backend, args...)
+	doc := assertMatchesSchema(t, "sync-trailers", []byte(stdout))
+	var results []map[string]interface{}
+	for _, item := range doc["results"].([]interface{}) {
+		results = append(results, item.(map[string]interface{}))
+	}
+	return results, err
+}
+
+func trailerStatuses(results []map[string]interface{}) []string {
+	statuses := make([]string, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result["reference"].(string)+"="+result["status"].(string))
+	}
+	return statuses
+}
+
+func TestSyncTrailersParsesReferences(t *testing.T) {
+	base, head := newGitRepo(t)
+	backend := newTestBackend(head)
+	backend.PullRequests[0].Threads = append(backend.PullRequests[0].Threads, &ghfake.Thread{
+		ID:   "PRRT_second",
+		Path: "main.go",
+		Line: intPtr(1),
+		Comments: []*ghfake.Comment{{
+			ID:     "PRRC_second",
+			Author: "bob",
+			Body:   "Package doc?",
+			URL:    secondThreadURL,
+		}},
+	})
+	followUp := commitTrailers(t, "Tidy up\n\n"+
+		"Addresses-Review: PRRT_seed1 is only mentioned in prose here.\n\n"+
+		"Addresses-Review: PRRC_seed1, https://github.com/acme/widgets/pull/7#r4242\n"+
+		"Addresses-Review: PRRT_unknown\n"+
+		"Fixes-Thread: PRRT_second")
+	pushCommit(backend, followUp)
+
+	results, err := syncTrailers(t, backend, base, "--dry-run")
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := []string{
+		testThread + "=planned",
+		"PRRC_seed1=planned",
+		"https://github.com/acme/widgets/pull/7#r4242=planned",
+		"PRRT_unknown=not_found",
+	}
+	if got := trailerStatuses(results); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if results[0]["commit"] != head || results[1]["commit"] != followUp {
+		t.Errorf("commits = %v, %v, want %s then %s", results[0]["commit"], results[1]["commit"], head, followUp)
+	}
+	if results[1]["thread_id"] != testThread || results[2]["thread_id"] != "PRRT_second" {
+		t.Errorf("thread ids = %v, %v, want the threads of the comment and its URL", results[1]["thread_id"], results[2]["thread_id"])
+	}
+	if results[3]["error"] == nil {
+		t.Error("unknown reference has no error")
+	}
+	if got := len(backend.PullRequests[0].Threads[0].Comments); got != 1 {
+		t.Errorf("dry run posted %d replies", got-1)
+	}
+
+	results, err = syncTrailers(t, backend, base, "--dry-run", "--trailer", "Fixes-Thread")
+	if err != nil {
+		t.Fatalf("custom trailer: %v", err)
+	}
+	if got := trailerStatuses(results); len(got) != 1 || got[0] != "PRRT_second=planned" {
+		t.Errorf("custom trailer statuses = %v, want only PRRT_second", got)
+	}
+
+	if _, _, err := runCLI(t, backend, "sync-trailers", "7", "-R", "acme/widgets", "--trailer", "Bad Key"); err == nil {
+		t.Error("expected an error for an invalid trailer key")
+	}
+}
+
+func TestSyncTrailersRerunIsNoOp(t *testing.T) {
+	base, head := newGitRepo(t)
+	backend := newTestBackend(head)
+	unpushed := commitTrailers(t, "Follow-up\n\nAddresses-Review: "+testThread)
+	thread := backend.PullRequests[0].Threads[0]
+
+	results, err := syncTrailers(t, backend, base)
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if got := trailerStatuses(results); got[0] != testThread+"=addressed" || got[1] != testThread+"=not_pushed" {
+		t.Fatalf("first run statuses = %v", got)
+	}
+	if !thread.IsResolved || len(thread.Comments) != 2 || !strings.Contains(thread.Comments[1].Body, head[:7]) {
+		t.Fatalf("thread after first run: resolved %v, %d comments", thread.IsResolved, len(thread.Comments))
+	}
+
+	data, err := os.ReadFile(filepath.Join(".git", trailerStateFile))
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	if !strings.Contains(string(data), `"github.com/acme/widgets#7"`) || !strings.Contains(string(data), head+" "+testThread) {
+		t.Errorf("state = %s, want the processed pair under the pull request", data)
+	}
+
+	results, err = syncTrailers(t, backend, base)
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if got := trailerStatuses(results); got[0] != testThread+"=already_processed" || got[1] != testThread+"=not_pushed" {
+		t.Errorf("rerun statuses = %v", got)
+	}
+	if len(thread.Comments) != 2 {
+		t.Errorf("rerun posted again: %d comments", len(thread.Comments))
+	}
+
+	pushCommit(backend, unpushed)
+	results, err = syncTrailers(t, backend, base)
+	if err != nil {
+		t.Fatalf("run after push: %v", err)
+	}
+	if got := trailerStatuses(results); got[0] != testThread+"=already_processed" || got[1] != testThread+"=addressed" {
+		t.Errorf("statuses after push = %v", got)
+	}
+	if len(thread.Comments) != 3 || !strings.Contains(thread.Comments[2].Body, unpushed[:7]) {
+		t.Errorf("pushed commit was not linked: %d comments", len(thread.Comments))
+	}
+}
+
+func TestSyncTrailersRetriesFailedReply(t *testing.T) {
+	base, head := newGitRepo(t)
+	backend := newTestBackend(head)
+	thread := backend.PullRequests[0].Threads[0]
+	failing := func(operation string) {
+		backend.GraphQLErrors = []ghfake.SeededError{{
+			Operation:         operation,
+			GraphQLErrorEntry: ghcli.GraphQLErrorEntry{Message: "something went wrong"},
+		}}
+	}
+
+	failing("AddPullRequestReviewThreadReply")
+	results, err := syncTrailers(t, backend, base)
+	if err == nil || err.Error() != "1 referenced threads could not be addressed" {
+		t.Fatalf("err = %v, want the failed reply counted", err)
+	}
+	if results[0]["status"] != "failed" || !strings.HasPrefix(results[0]["error"].(string), "reply: ") {
+		t.Fatalf("result = %v, want a failed reply", results[0])
+	}
+	if _, err := os.Stat(filepath.Join(".git", trailerStateFile)); !os.IsNotExist(err) {
+		t.Errorf("state was saved for a failed reply: %v", err)
+	}
+
+	backend.GraphQLErrors = nil
+	results, err = syncTrailers(t, backend, base)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if results[0]["status"] != "addressed" || len(thread.Comments) != 2 || !thread.IsResolved {
+		t.Fatalf("retry result = %v, %d comments, resolved %v", results[0], len(thread.Comments), thread.IsResolved)
+	}
+}
+
+func TestSyncTrailersDoesNotRepostAfterResolveFails(t *testing.T) {
+	base, head := newGitRepo(t)
+	backend := newTestBackend(head)
+	thread := backend.PullRequests[0].Threads[0]
+	backend.GraphQLErrors = []ghfake.SeededError{{
+		Operation:         "ResolveReviewThread",
+		GraphQLErrorEntry: ghcli.GraphQLErrorEntry{Message: "something went wrong"},
+	}}
+
+	results, err := syncTrailers(t, backend, base)
+	if err == nil {
+		t.Fatal("expected the failed resolve to be reported")
+	}
+	if results[0]["status"] != "failed" || results[0]["comment"] == nil || !strings.HasPrefix(results[0]["error"].(string), "resolve: ") {
+		t.Fatalf("result = %v, want a posted reply and a failed resolve", results[0])
+	}
+
+	backend.GraphQLErrors = nil
+	results, err = syncTrailers(t, backend, base)
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if results[0]["status"] != "already_processed" || len(thread.Comments) != 2 {
+		t.Errorf("rerun result = %v with %d comments, want the reply not posted again", results[0], len(thread.Comments))
+	}
+}
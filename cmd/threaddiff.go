@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/git"
+)
+
+const formatText = "text"
+
+type threadDiffOptions struct {
+	Hostname string
+	Remote   string
+	Context  int
+	Format   string
+	ThreadID string
+}
+
+func newThreadDiffCommand() *cobra.Command {
+	opts := &threadDiffOptions{Remote: "origin", Context: 3, Format: formatJSON}
+
+	cmd := &cobra.Command{
+		Use:   "thread-diff <thread-id>",
+		Short: "Show how the commented code changed since a thread was opened",
+		Long: "Diff the lines a review thread was opened on between the thread's original commit\n" +
+			"and the current pull request head, using the local git repository. Missing commits\n" +
+			"are fetched from the remote.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ThreadID = args[0]
+			return runThreadDiff(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Hostname, "hostname", "", "GitHub host (defaults to the gh default host)")
+	cmd.Flags().StringVar(&opts.Remote, "remote", opts.Remote, "Git remote to fetch missing commits from")
+	cmd.Flags().IntVarP(&opts.Context, "context", "U", opts.Context, "Lines of context around changes")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "Output format: json or text")
+
+	return cmd
+}
+
+type threadDiffLine struct {
+	Kind    string `json:"kind"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Text    string `json:"text"`
+}
+
+type threadDiffHunk struct {
+	Header string           `json:"header"`
+	Lines  []threadDiffLine `json:"lines"`
+}
+
+func runThreadDiff(cmd *cobra.Command, opts *threadDiffOptions) error {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format != formatJSON && format != formatText {
+		return fmt.Errorf("invalid format %q: must be %s or %s", opts.Format, formatJSON, formatText)
+	}
+	if opts.Context < 0 {
+		return fmt.Errorf("--context must not be negative")
+	}
+
+	service := comments.NewService(apiClientFactory(opts.Hostname))
+	anchor, err := service.Anchor(opts.ThreadID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(anchor.DiffSide, "LEFT") {
+		return fmt.Errorf("thread %s is on removed lines; only threads on the new side of the diff can be traced", anchor.ID)
+	}
+	start, end, err := anchor.OriginalRange()
+	if err != nil {
+		return err
+	}
+
+	if err := ensureCommits(opts.Remote, anchor.PullRequest, anchor.OriginalCommit, anchor.HeadCommit); err != nil {
+		return err
+	}
+	original, err := git.Show(anchor.OriginalCommit, anchor.Path)
+	if err != nil {
+		return fmt.Errorf("read %s at %s: %w", anchor.Path, shortCommit(anchor.OriginalCommit), err)
+	}
+	patch, err := git.Diff(anchor.OriginalCommit, anchor.HeadCommit, anchor.Path, opts.Context)
+	if err != nil {
+		return fmt.Errorf("diff %s: %w", anchor.Path, err)
+	}
+	region := diff.TraceRegion(diff.Parse(patch), start, end)
+
+	if format == formatText {
+		return writeThreadDiffText(cmd.OutOrStdout(), anchor, start, end, original, region)
+	}
+
+	thread := map[string]interface{}{
+		"id":                  anchor.ID,
+		"path":                anchor.Path,
+		"pull_request":        anchor.PullRequest,
+		"is_outdated":         anchor.IsOutdated,
+		"original_commit":     anchor.OriginalCommit,
+		"head_commit":         anchor.HeadCommit,
+		"original_start_line": start,
+		"original_line":       end,
+	}
+	payload := map[string]interface{}{
+		"thread":   thread,
+		"changed":  region.Changed,
+		"original": fileLines(original, start, end),
+		"hunks":    threadDiffHunks(region.Hunks),
+	}
+	if region.NewStart > 0 {
+		payload["current_start_line"] = region.NewStart
+	}
+	if region.NewEnd > 0 {
+		payload["current_line"] = region.NewEnd
+	}
+	return encodeJSON(cmd, payload)
+}
+
+// ensureCommits makes the commits available locally, first by fetching the pull
+// request head ref and then, for commits dropped by a force-push, by SHA.
+func ensureCommits(remote string, number int, shas ...string) error {
+	missing := func() []string {
+		var out []string
+		for _, sha := range shas {
+			if !git.HasCommit(sha) {
+				out = append(out, sha)
+			}
+		}
+		return out
+	}
+	if len(missing()) == 0 {
+		return nil
+	}
+	// The pull request ref usually brings every commit along; fetching by SHA is
+	// the fallback, so the ref's error only matters when that fails too.
+	pullRef := fmt.Sprintf("refs/pull/%d/head", number)
+	refErr := git.Fetch(remote, pullRef)
+	for _, sha := range missing() {
+		if err := git.Fetch(remote, sha); err != nil {
+			if refErr != nil {
+				return fmt.Errorf("commit %s is not available locally and could not be fetched from %s: %w (fetching %s also failed: %v)", shortCommit(sha), remote, err, pullRef, refErr)
+			}
+			return fmt.Errorf("commit %s is not available locally and could not be fetched from %s: %w", shortCommit(sha), remote, err)
+		}
+	}
+	return nil
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// fileLines returns lines start..end (1-based, inclusive) of content.
+func fileLines(content string, start, end int) []string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	out := make([]string, 0, end-start+1)
+	for n := start; n <= end && n <= len(lines); n++ {
+		if n >= 1 {
+			out = append(out, lines[n-1])
+		}
+	}
+	return out
+}
+
+func threadDiffHunks(hunks []diff.Hunk) []threadDiffHunk {
+	out := make([]threadDiffHunk, 0, len(hunks))
+	for _, h := range hunks {
+		hunk := threadDiffHunk{
+			Header: fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines),
+			Lines:  make([]threadDiffLine, 0, len(h.Lines)),
+		}
+		for _, l := range h.Lines {
+			kind := "context"
+			switch l.Kind {
+			case diff.Added:
+				kind = "added"
+			case diff.Removed:
+				kind = "removed"
+			}
+			hunk.Lines = append(hunk.Lines, threadDiffLine{Kind: kind, OldLine: l.OldLine, NewLine: l.NewLine, Text: l.Text})
+		}
+		out = append(out, hunk)
+	}
+	return out
+}
+
+func writeThreadDiffText(w io.Writer, anchor comments.ThreadAnchor, start, end int, original string, region diff.Region) error {
+	renderer := lipgloss.NewRenderer(w)
+	bold := renderer.NewStyle().Bold(true)
+	faint := renderer.NewStyle().Faint(true)
+	hunkStyle := renderer.NewStyle().Foreground(lipgloss.Color("6"))
+	addStyle := renderer.NewStyle().Foreground(lipgloss.Color("2")).TabWidth(lipgloss.NoTabConversion)
+	delStyle := renderer.NewStyle().Foreground(lipgloss.Color("1")).TabWidth(lipgloss.NoTabConversion)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", bold.Render(fmt.Sprintf("%s:%s", anchor.Path, lineRange(start, end))),
+		faint.Render(fmt.Sprintf("%s..%s", shortCommit(anchor.OriginalCommit), shortCommit(anchor.HeadCommit))))
+
+	switch {
+	case region.Changed:
+		status := "changed"
+		if region.NewStart > 0 && region.NewEnd > 0 {
+			status += ", now around " + lineRange(region.NewStart, region.NewEnd)
+		}
+		b.WriteString(status + "\n")
+		for _, h := range threadDiffHunks(region.Hunks) {
+			b.WriteString(hunkStyle.Render(h.Header) + "\n")
+			for _, l := range h.Lines {
+				switch l.Kind {
+				case "added":
+					b.WriteString(addStyle.Render("+"+l.Text) + "\n")
+				case "removed":
+					b.WriteString(delStyle.Render("-"+l.Text) + "\n")
+				default:
+					b.WriteString(" " + l.Text + "\n")
+				}
+			}
+		}
+	case region.NewStart == 0:
+		b.WriteString("removed\n")
+	default:
+		fmt.Fprintf(&b, "unchanged, now at %s\n", lineRange(region.NewStart, region.NewEnd))
+		for i, line := range fileLines(original, start, end) {
+			fmt.Fprintf(&b, "%s %s\n", faint.Render(fmt.Sprintf("%5d", region.NewStart+i)), line)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func lineRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
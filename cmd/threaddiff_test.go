@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnsureCommitsReportsBothFetchErrors(t *testing.T) {
+	_, head := newGitRepo(t)
+
+	if err := ensureCommits("origin", 7, head); err != nil {
+		t.Fatalf("local commit: %v", err)
+	}
+
+	missing := strings.Repeat("ab", 20)
+	err := ensureCommits("origin", 7, head, missing)
+	if err == nil {
+		t.Fatal("expected an error for a commit that cannot be fetched")
+	}
+	for _, want := range []string{"commit abababa is not available locally", "fetching refs/pull/7/head also failed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,588 @@
+// Package browse implements the full-screen thread browser behind
+// `gh pr-comments browse`.
+package browse
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/agynio/gh-pr-review/internal/comments"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// Service is the subset of comments.Service the browser needs.
+type Service interface {
+	List(pr resolver.Identity) ([]comments.Thread, []comments.Warning, error)
+	Reply(threadID, body string) (comments.Comment, error)
+	Resolve(threadID string) (comments.ResolveResult, error)
+	Unresolve(threadID string) (comments.ResolveResult, error)
+	React(subjectID, reaction string) (comments.ReactionResult, error)
+}
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeReply
+	modeReact
+)
+
+// row is one line of the thread list: a file header or a thread.
+type row struct {
+	path   string
+	thread int // index into Model.threads, or -1 for a file header
+}
+
+// Model is the bubbletea model of the browser.
+type Model struct {
+	service Service
+	pr      resolver.Identity
+	open    func(url string) error
+
+	threads        []comments.Thread
+	rows           []row
+	cursor         int
+	listOffset     int
+	detailOffset   int
+	unresolvedOnly bool
+
+	mode    mode
+	input   []rune
+	status  string
+	loading bool
+
+	width  int
+	height int
+}
+
+// New creates a browser for pr. open is used to show threads in a web browser.
+func New(service Service, pr resolver.Identity, open func(url string) error) *Model {
+	return &Model{service: service, pr: pr, open: open, loading: true, width: 80, height: 24}
+}
+
+type threadsLoadedMsg struct {
+	threads  []comments.Thread
+	warnings []comments.Warning
+	err      error
+}
+
+type actionDoneMsg struct {
+	status string
+	err    error
+}
+
+func (m *Model) load() tea.Cmd {
+	service, pr := m.service, m.pr
+	return func() tea.Msg {
+		threads, warnings, err := service.List(pr)
+		return threadsLoadedMsg{threads: threads, warnings: warnings, err: err}
+	}
+}
+
+// Init implements tea.Model.
+func (m *Model) Init() tea.Cmd {
+	return m.load()
+}
+
+// Update implements tea.Model.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clampList()
+		return m, nil
+
+	case threadsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.setThreads(msg.threads)
+		if n := len(msg.warnings); n > 0 {
+			m.status = "warning: " + msg.warnings[0].String()
+			if n > 1 {
+				m.status += fmt.Sprintf(" (+%d more)", n-1)
+			}
+		}
+		return m, nil
+
+	case actionDoneMsg:
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = msg.status
+		m.loading = true
+		return m, m.load()
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case modeReply:
+			return m.updateReply(msg)
+		case modeReact:
+			return m.updateReact(msg)
+		}
+		return m.updateNormal(msg)
+	}
+	return m, nil
+}
+
+func (m *Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "j", "down":
+		m.move(1)
+	case "k", "up":
+		m.move(-1)
+	case "g", "home":
+		m.cursor = 0
+		m.move(0)
+	case "G", "end":
+		m.cursor = len(m.rows) - 1
+		m.move(0)
+	case "ctrl+d", "pgdown", "J":
+		m.detailOffset += m.bodyHeight() / 2
+	case "ctrl+u", "pgup", "K":
+		m.detailOffset -= m.bodyHeight() / 2
+		if m.detailOffset < 0 {
+			m.detailOffset = 0
+		}
+	case "f":
+		m.unresolvedOnly = !m.unresolvedOnly
+		m.setThreads(m.threads)
+	case "R", "ctrl+r":
+		m.loading = true
+		m.status = "refreshing…"
+		return m, m.load()
+	case "r":
+		if m.selected() != nil {
+			m.mode = modeReply
+			m.input = nil
+		}
+	case "e":
+		if m.selected() != nil {
+			m.mode = modeReact
+		}
+	case "x":
+		if thread := m.selected(); thread != nil {
+			return m, m.toggleResolved(*thread)
+		}
+	case "o":
+		if thread := m.selected(); thread != nil && len(thread.Comments) > 0 {
+			if err := m.open(thread.Comments[0].URL); err != nil {
+				m.status = "error: " + err.Error()
+			} else {
+				m.status = "opened " + thread.Comments[0].URL
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateReply(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeNormal
+		m.status = "reply cancelled"
+		return m, nil
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEnter:
+		body := strings.TrimSpace(string(m.input))
+		m.mode = modeNormal
+		thread := m.selected()
+		if body == "" || thread == nil {
+			m.status = "reply cancelled"
+			return m, nil
+		}
+		service, id := m.service, thread.ID
+		m.status = "posting reply…"
+		return m, func() tea.Msg {
+			_, err := service.Reply(id, body)
+			return actionDoneMsg{status: "reply posted", err: err}
+		}
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeySpace:
+		m.input = append(m.input, ' ')
+	case tea.KeyRunes:
+		m.input = append(m.input, msg.Runes...)
+	}
+	return m, nil
+}
+
+func (m *Model) updateReact(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+	m.mode = modeNormal
+	thread := m.selected()
+	if thread == nil || len(thread.Comments) == 0 {
+		return m, nil
+	}
+	var choice int
+	if _, err := fmt.Sscanf(key, "%d", &choice); err != nil || choice < 1 || choice > len(comments.ReactionNames) {
+		m.status = "reaction cancelled"
+		return m, nil
+	}
+	reaction := comments.ReactionNames[choice-1]
+	service, id := m.service, thread.Comments[len(thread.Comments)-1].ID
+	m.status = "reacting…"
+	return m, func() tea.Msg {
+		_, err := service.React(id, reaction)
+		return actionDoneMsg{status: "reacted " + reaction + " to the latest comment", err: err}
+	}
+}
+
+func (m *Model) toggleResolved(thread comments.Thread) tea.Cmd {
+	service := m.service
+	if thread.IsResolved {
+		m.status = "unresolving…"
+		return func() tea.Msg {
+			_, err := service.Unresolve(thread.ID)
+			return actionDoneMsg{status: "thread unresolved", err: err}
+		}
+	}
+	m.status = "resolving…"
+	return func() tea.Msg {
+		_, err := service.Resolve(thread.ID)
+		return actionDoneMsg{status: "thread resolved", err: err}
+	}
+}
+
+// setThreads sorts threads by file and line and rebuilds the rows, keeping the
+// selection on the same thread when it is still listed.
+func (m *Model) setThreads(threads []comments.Thread) {
+	selectedID := ""
+	if thread := m.selected(); thread != nil {
+		selectedID = thread.ID
+	}
+
+	m.threads = append([]comments.Thread(nil), threads...)
+	sort.SliceStable(m.threads, func(i, j int) bool {
+		if m.threads[i].Path != m.threads[j].Path {
+			return m.threads[i].Path < m.threads[j].Path
+		}
+		return threadLine(m.threads[i]) < threadLine(m.threads[j])
+	})
+
+	m.rows = m.rows[:0]
+	lastPath := ""
+	for i, thread := range m.threads {
+		if m.unresolvedOnly && thread.IsResolved {
+			continue
+		}
+		if thread.Path != lastPath || len(m.rows) == 0 {
+			m.rows = append(m.rows, row{path: thread.Path, thread: -1})
+			lastPath = thread.Path
+		}
+		m.rows = append(m.rows, row{path: thread.Path, thread: i})
+	}
+
+	m.cursor = 0
+	for i, r := range m.rows {
+		if r.thread >= 0 && m.threads[r.thread].ID == selectedID {
+			m.cursor = i
+			break
+		}
+	}
+	m.move(0)
+}
+
+// move shifts the cursor by delta, skipping file headers. A delta of zero
+// settles the cursor on the nearest thread.
+func (m *Model) move(delta int) {
+	if len(m.rows) == 0 {
+		m.cursor = 0
+		return
+	}
+	dir := 1
+	if delta < 0 {
+		dir = -1
+	}
+	target := m.cursor + delta
+	if target < 0 {
+		target = 0
+	}
+	if target >= len(m.rows) {
+		target = len(m.rows) - 1
+	}
+
+	cursor := m.nextThread(target, dir)
+	if cursor < 0 {
+		cursor = m.nextThread(target, -dir)
+	}
+	if cursor < 0 {
+		return
+	}
+	if cursor != m.cursor {
+		m.detailOffset = 0
+	}
+	m.cursor = cursor
+	m.clampList()
+}
+
+// nextThread returns the first thread row at or after from in direction dir, or -1.
+func (m *Model) nextThread(from, dir int) int {
+	for i := from; i >= 0 && i < len(m.rows); i += dir {
+		if m.rows[i].thread >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Model) clampList() {
+	height := m.bodyHeight()
+	if m.cursor < m.listOffset {
+		m.listOffset = m.cursor
+		// Keep the file header of the first visible thread in view.
+		if m.listOffset > 0 && m.rows[m.listOffset-1].thread < 0 {
+			m.listOffset--
+		}
+	}
+	if m.cursor >= m.listOffset+height {
+		m.listOffset = m.cursor - height + 1
+	}
+	if m.listOffset < 0 {
+		m.listOffset = 0
+	}
+}
+
+func (m *Model) selected() *comments.Thread {
+	if m.cursor < 0 || m.cursor >= len(m.rows) || m.rows[m.cursor].thread < 0 {
+		return nil
+	}
+	return &m.threads[m.rows[m.cursor].thread]
+}
+
+func threadLine(t comments.Thread) int {
+	for _, line := range []*int{t.Line, t.OriginalLine} {
+		if line != nil {
+			return *line
+		}
+	}
+	return 0
+}
+
+// bodyHeight is the number of lines available to the panes, below the title
+// and above the status and help lines.
+func (m *Model) bodyHeight() int {
+	if h := m.height - 3; h > 1 {
+		return h
+	}
+	return 1
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	fileStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	resolvedStyle = lipgloss.NewStyle().Faint(true)
+	addStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	delStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	hunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	authorStyle   = lipgloss.NewStyle().Bold(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+// View implements tea.Model.
+func (m *Model) View() string {
+	title := titleStyle.Render(fmt.Sprintf("%s/%s#%d", m.pr.Owner, m.pr.Repo, m.pr.Number))
+	unresolved := 0
+	for _, t := range m.threads {
+		if !t.IsResolved {
+			unresolved++
+		}
+	}
+	title += fmt.Sprintf("  %d threads, %d unresolved", len(m.threads), unresolved)
+	if m.unresolvedOnly {
+		title += "  [unresolved only]"
+	}
+	if m.loading {
+		title += "  loading…"
+	}
+
+	listWidth := m.width * 2 / 5
+	if listWidth < 20 {
+		listWidth = 20
+	}
+	detailWidth := m.width - listWidth - 3
+	if detailWidth < 20 {
+		detailWidth = 20
+	}
+
+	height := m.bodyHeight()
+	list := m.renderList(listWidth, height)
+	detail := m.renderDetail(detailWidth, height)
+	separator := strings.TrimRight(strings.Repeat("│\n", height), "\n")
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth).Height(height).Render(list),
+		" "+separator+" ",
+		lipgloss.NewStyle().Width(detailWidth).Height(height).Render(detail),
+	)
+
+	var footer string
+	switch m.mode {
+	case modeReply:
+		footer = "reply> " + string(m.input) + "█"
+	case modeReact:
+		choices := make([]string, 0, len(comments.ReactionNames))
+		for i, name := range comments.ReactionNames {
+			choices = append(choices, fmt.Sprintf("%d %s", i+1, name))
+		}
+		footer = "react: " + strings.Join(choices, "  ") + "  (other key cancels)"
+	default:
+		footer = m.status
+	}
+	help := helpStyle.Render(truncate("j/k move  J/K scroll  r reply  x resolve/unresolve  e react  o open  f unresolved only  R refresh  q quit", m.width))
+
+	return strings.Join([]string{title, body, truncate(footer, m.width), help}, "\n")
+}
+
+func (m *Model) renderList(width, height int) string {
+	if len(m.rows) == 0 {
+		if m.loading {
+			return ""
+		}
+		return "No review threads."
+	}
+	end := m.listOffset + height
+	if end > len(m.rows) {
+		end = len(m.rows)
+	}
+	lines := make([]string, 0, height)
+	for i := m.listOffset; i < end; i++ {
+		r := m.rows[i]
+		if r.thread < 0 {
+			lines = append(lines, fileStyle.Render(truncate(r.path, width)))
+			continue
+		}
+		thread := m.threads[r.thread]
+		marker := "●"
+		if thread.IsResolved {
+			marker = "✓"
+		}
+		summary := ""
+		if len(thread.Comments) > 0 {
+			first := thread.Comments[0]
+			summary = "@" + first.Author.Login + " " + firstLine(first.Body)
+		}
+		label := fmt.Sprintf(" %s %4d %s", marker, threadLine(thread), summary)
+		if thread.IsOutdated {
+			label += " (outdated)"
+		}
+		label = truncate(label, width)
+		switch {
+		case i == m.cursor:
+			label = cursorStyle.Render(label + strings.Repeat(" ", max(0, width-lipgloss.Width(label))))
+		case thread.IsResolved:
+			label = resolvedStyle.Render(label)
+		}
+		lines = append(lines, label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) renderDetail(width, height int) string {
+	thread := m.selected()
+	if thread == nil {
+		return ""
+	}
+
+	var lines []string
+	state := "unresolved"
+	if thread.IsResolved {
+		state = "resolved"
+	}
+	if thread.IsOutdated {
+		state += ", outdated"
+	}
+	lines = append(lines, titleStyle.Render(fmt.Sprintf("%s:%d", thread.Path, threadLine(*thread)))+"  "+state, "")
+
+	for _, line := range strings.Split(strings.TrimRight(thread.DiffHunk, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		line = truncate(line, width)
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			line = hunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = addStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = delStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	wrap := lipgloss.NewStyle().Width(width)
+	for _, c := range thread.Comments {
+		lines = append(lines, "", authorStyle.Render("@"+c.Author.Login)+"  "+helpStyle.Render(c.CreatedAt))
+		body := c.Body
+		if c.IsMinimized {
+			body = "(hidden: " + c.MinimizedReason + ")"
+		}
+		lines = append(lines, strings.Split(wrap.Render(body), "\n")...)
+	}
+
+	if m.detailOffset > len(lines)-1 {
+		m.detailOffset = max(0, len(lines)-1)
+	}
+	lines = lines[m.detailOffset:]
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+func truncate(text string, width int) string {
+	if width <= 0 || lipgloss.Width(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > width-1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// OpenURL opens url in the user's web browser, honoring $BROWSER.
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch browser := os.Getenv("BROWSER"); {
+	case browser != "":
+		cmd = exec.Command(browser, url)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", url)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open browser: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,200 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const pullRequestCommitsQuery = `query PullRequestCommits($owner: String!, $name: String!, $number: Int!, $firstCommits: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      baseRefName
+      headRefName
+      headRefOid
+      commits(first: $firstCommits, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          commit {
+            oid
+            url
+          }
+        }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+const commitsPageSize = 100
+
+// maxAmbiguousCommits bounds the matches listed when an abbreviated SHA is ambiguous.
+const maxAmbiguousCommits = 3
+
+// PullRequestCommit is a commit on a pull request branch.
+type PullRequestCommit struct {
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
+
+// PullRequestBranch describes the branches of a pull request and its commits.
+type PullRequestBranch struct {
+	BaseRef string
+	HeadRef string
+	HeadSHA string
+	Commits []PullRequestCommit
+}
+
+// Find looks up a commit by full or abbreviated SHA. It reports false when no
+// commit matches, and fails when an abbreviated SHA matches several commits.
+func (b PullRequestBranch) Find(sha string) (PullRequestCommit, bool, error) {
+	sha = strings.ToLower(strings.TrimSpace(sha))
+	if sha == "" {
+		return PullRequestCommit{}, false, nil
+	}
+	matches := make([]PullRequestCommit, 0, 1)
+	for _, commit := range b.Commits {
+		if strings.HasPrefix(strings.ToLower(commit.SHA), sha) {
+			matches = append(matches, commit)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return PullRequestCommit{}, false, nil
+	case 1:
+		return matches[0], true, nil
+	}
+	shas := make([]string, 0, maxAmbiguousCommits+1)
+	for i, commit := range matches {
+		if i == maxAmbiguousCommits {
+			shas = append(shas, fmt.Sprintf("%d more", len(matches)-i))
+			break
+		}
+		shas = append(shas, commit.SHA)
+	}
+	return PullRequestCommit{}, false, fmt.Errorf("commit %s is ambiguous: it matches %s; use a longer SHA", sha, strings.Join(shas, ", "))
+}
+
+// AddressResult reports the outcome of addressing one thread. Comment is set
+// once the reply is posted, even when resolving the thread then fails.
+type AddressResult struct {
+	ThreadID   string   `json:"thread_id"`
+	Comment    *Comment `json:"comment,omitempty"`
+	IsResolved bool     `json:"is_resolved"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Branch fetches the base and head branches of a pull request with all of its
+// commits, oldest first, paging through the commits connection.
+func (s *Service) Branch(pr resolver.Identity) (PullRequestBranch, error) {
+	var branch PullRequestBranch
+	var after *string
+	for {
+		variables := map[string]interface{}{
+			"owner":        pr.Owner,
+			"name":         pr.Repo,
+			"number":       pr.Number,
+			"firstCommits": commitsPageSize,
+		}
+		if after != nil {
+			variables["after"] = *after
+		}
+
+		var response struct {
+			Repository *struct {
+				PullRequest *struct {
+					BaseRefName string `json:"baseRefName"`
+					HeadRefName string `json:"headRefName"`
+					HeadRefOid  string `json:"headRefOid"`
+					Commits     struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							Commit struct {
+								Oid string `json:"oid"`
+								URL string `json:"url"`
+							} `json:"commit"`
+						} `json:"nodes"`
+					} `json:"commits"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := s.API.GraphQL(pullRequestCommitsQuery, variables, &response); err != nil {
+			return PullRequestBranch{}, err
+		}
+		if response.Repository == nil || response.Repository.PullRequest == nil {
+			return PullRequestBranch{}, fmt.Errorf("pull request %s/%s#%d not found", pr.Owner, pr.Repo, pr.Number)
+		}
+
+		pull := response.Repository.PullRequest
+		branch.BaseRef = pull.BaseRefName
+		branch.HeadRef = pull.HeadRefName
+		branch.HeadSHA = pull.HeadRefOid
+		for _, node := range pull.Commits.Nodes {
+			branch.Commits = append(branch.Commits, PullRequestCommit{SHA: node.Commit.Oid, URL: node.Commit.URL})
+		}
+
+		if !pull.Commits.PageInfo.HasNextPage {
+			break
+		}
+		cursor := pull.Commits.PageInfo.EndCursor
+		after = &cursor
+	}
+	if branch.Commits == nil {
+		branch.Commits = make([]PullRequestCommit, 0)
+	}
+	return branch, nil
+}
+
+// FindCommit looks up a commit (full or abbreviated SHA) among the commits of
+// the pull request, failing when it has not been pushed to the branch.
+func (s *Service) FindCommit(pr resolver.Identity, sha string) (PullRequestCommit, error) {
+	if strings.TrimSpace(sha) == "" {
+		return PullRequestCommit{}, errors.New("commit is required")
+	}
+	branch, err := s.Branch(pr)
+	if err != nil {
+		return PullRequestCommit{}, err
+	}
+	commit, ok, err := branch.Find(sha)
+	if err != nil {
+		return PullRequestCommit{}, err
+	}
+	if !ok {
+		return PullRequestCommit{}, fmt.Errorf("commit %s is not on the %s branch of pull request #%d; push it first", shortSHA(strings.TrimSpace(sha)), branch.HeadRef, pr.Number)
+	}
+	return commit, nil
+}
+
+// Address replies to a thread with a link to the fixing commit and resolves it.
+// An optional message is posted above the link.
+func (s *Service) Address(threadID string, commit PullRequestCommit, message string) AddressResult {
+	result := AddressResult{ThreadID: strings.TrimSpace(threadID)}
+
+	body := fmt.Sprintf("Fixed in [%s](%s).", shortSHA(commit.SHA), commit.URL)
+	if message = strings.TrimSpace(message); message != "" {
+		body = message + "\n\n" + body
+	}
+
+	comment, err := s.Reply(threadID, body)
+	if err != nil {
+		result.Error = fmt.Sprintf("reply: %v", err)
+		return result
+	}
+	result.Comment = &comment
+
+	resolved, err := s.Resolve(threadID)
+	if err != nil {
+		result.Error = fmt.Sprintf("resolve: %v", err)
+		return result
+	}
+	result.IsResolved = resolved.IsResolved
+	return result
+}
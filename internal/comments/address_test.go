@@ -0,0 +1,87 @@
+package comments
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+func TestFindCommitPagesThroughCommits(t *testing.T) {
+	backend := ghfake.New("github.com", "octocat")
+	commits := make([]ghfake.Commit, 0, 250)
+	for i := 0; i < 250; i++ {
+		commits = append(commits, ghfake.Commit{SHA: fmt.Sprintf("%03x%037x", i+1, 0), Headline: fmt.Sprintf("commit %d", i+1)})
+	}
+	backend.AddPullRequest(&ghfake.PullRequest{
+		Owner:   "acme",
+		Repo:    "widgets",
+		Number:  7,
+		HeadRef: "feature",
+		HeadSHA: commits[len(commits)-1].SHA,
+		Commits: commits,
+	})
+	service := NewService(backend.Client())
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 7}
+
+	branch, err := service.Branch(pr)
+	if err != nil {
+		t.Fatalf("branch: %v", err)
+	}
+	if len(branch.Commits) != 250 || branch.Commits[0].SHA != commits[0].SHA {
+		t.Fatalf("got %d commits starting at %s, want all 250 oldest first", len(branch.Commits), branch.Commits[0].SHA)
+	}
+
+	found, err := service.FindCommit(pr, commits[0].SHA[:12])
+	if err != nil {
+		t.Fatalf("find first commit: %v", err)
+	}
+	if found.SHA != commits[0].SHA {
+		t.Fatalf("found %s, want %s", found.SHA, commits[0].SHA)
+	}
+
+	if _, err := service.FindCommit(pr, "deadbeef"); err == nil {
+		t.Fatal("expected an error for a commit that is not on the branch")
+	}
+}
+
+func TestPullRequestBranchFind(t *testing.T) {
+	branch := PullRequestBranch{Commits: []PullRequestCommit{
+		{SHA: "abc1234000000000000000000000000000000001"},
+		{SHA: "abc1234000000000000000000000000000000002"},
+		{SHA: "ABCDEF0000000000000000000000000000000003"},
+	}}
+
+	tests := []struct {
+		sha       string
+		want      string
+		found     bool
+		ambiguous bool
+	}{
+		{sha: "abc1234000000000000000000000000000000002", want: branch.Commits[1].SHA, found: true},
+		{sha: "abcdef0", want: branch.Commits[2].SHA, found: true},
+		{sha: " ABC1234000000000000000000000000000000001 ", want: branch.Commits[0].SHA, found: true},
+		{sha: "abc1234", ambiguous: true},
+		{sha: "ab", ambiguous: true},
+		{sha: "fff"},
+		{sha: "  "},
+	}
+	for _, tt := range tests {
+		commit, found, err := branch.Find(tt.sha)
+		if tt.ambiguous {
+			if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+				t.Errorf("Find(%q) err = %v, want an ambiguity error", tt.sha, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Find(%q): %v", tt.sha, err)
+			continue
+		}
+		if found != tt.found || commit.SHA != tt.want {
+			t.Errorf("Find(%q) = %q, %v, want %q, %v", tt.sha, commit.SHA, found, tt.want, tt.found)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const threadAnchorQuery = `query ReviewThreadAnchor($id: ID!) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      id
+      path
+      diffSide
+      isOutdated
+      line
+      startLine
+      originalLine
+      originalStartLine
+      pullRequest {
+        number
+        headRefOid
+      }
+      comments(first: 1) {
+        nodes {
+          originalCommit { oid }
+        }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+// ThreadAnchor locates the code a review thread was opened on.
+type ThreadAnchor struct {
+	ID                string
+	Path              string
+	DiffSide          string
+	IsOutdated        bool
+	Line              *int
+	StartLine         *int
+	OriginalLine      *int
+	OriginalStartLine *int
+	OriginalCommit    string
+	HeadCommit        string
+	PullRequest       int
+}
+
+// OriginalRange returns the first and last line the thread was opened on.
+func (a ThreadAnchor) OriginalRange() (int, int, error) {
+	if a.OriginalLine == nil {
+		return 0, 0, fmt.Errorf("thread %s is not anchored to a line", a.ID)
+	}
+	end := *a.OriginalLine
+	start := end
+	if a.OriginalStartLine != nil && *a.OriginalStartLine > 0 {
+		start = *a.OriginalStartLine
+	}
+	return start, end, nil
+}
+
+// Anchor fetches the path, lines and commits a review thread is anchored to.
+func (s *Service) Anchor(threadID string) (ThreadAnchor, error) {
+	id := strings.TrimSpace(threadID)
+	if id == "" {
+		return ThreadAnchor{}, errors.New("thread id is required")
+	}
+
+	var response struct {
+		Node *struct {
+			ID                string `json:"id"`
+			Path              string `json:"path"`
+			DiffSide          string `json:"diffSide"`
+			IsOutdated        bool   `json:"isOutdated"`
+			Line              *int   `json:"line"`
+			StartLine         *int   `json:"startLine"`
+			OriginalLine      *int   `json:"originalLine"`
+			OriginalStartLine *int   `json:"originalStartLine"`
+			PullRequest       *struct {
+				Number     int    `json:"number"`
+				HeadRefOid string `json:"headRefOid"`
+			} `json:"pullRequest"`
+			Comments struct {
+				Nodes []struct {
+					OriginalCommit *struct {
+						Oid string `json:"oid"`
+					} `json:"originalCommit"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"node"`
+	}
+
+	if err := s.API.GraphQL(threadAnchorQuery, map[string]interface{}{"id": id}, &response); err != nil {
+		return ThreadAnchor{}, err
+	}
+
+	node := response.Node
+	if node == nil || node.ID == "" || node.PullRequest == nil {
+		return ThreadAnchor{}, fmt.Errorf("review thread %s not found", id)
+	}
+	if len(node.Comments.Nodes) == 0 || node.Comments.Nodes[0].OriginalCommit == nil {
+		return ThreadAnchor{}, fmt.Errorf("review thread %s has no original commit", id)
+	}
+
+	return ThreadAnchor{
+		ID:                node.ID,
+		Path:              node.Path,
+		DiffSide:          node.DiffSide,
+		IsOutdated:        node.IsOutdated,
+		Line:              node.Line,
+		StartLine:         node.StartLine,
+		OriginalLine:      node.OriginalLine,
+		OriginalStartLine: node.OriginalStartLine,
+		OriginalCommit:    node.Comments.Nodes[0].OriginalCommit.Oid,
+		HeadCommit:        node.PullRequest.HeadRefOid,
+		PullRequest:       node.PullRequest.Number,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package comments
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Author types. GitHub reports the first three as the actor's __typename;
+// deleted accounts come back as a null author and are reported as ghost.
+const (
+	AuthorUser      = "User"
+	AuthorBot       = "Bot"
+	AuthorMannequin = "Mannequin"
+	AuthorGhost     = "ghost"
+)
+
+// ghostLogin is the login GitHub shows for deleted accounts.
+const ghostLogin = "ghost"
+
+// authorAssociations lists GitHub's CommentAuthorAssociation values.
+var authorAssociations = []string{
+	"OWNER", "MEMBER", "COLLABORATOR", "CONTRIBUTOR",
+	"FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER", "MANNEQUIN", "NONE",
+}
+
+// Author identifies who wrote a comment or review and how they relate to the repository.
+type Author struct {
+	Login       string `json:"login"`
+	Type        string `json:"type"`
+	Association string `json:"association,omitempty"`
+}
+
+// UnmarshalJSON also accepts the bare login string written by schema version 1
+// documents, so older export archives can still be imported.
+func (a *Author) UnmarshalJSON(data []byte) error {
+	var login string
+	if err := json.Unmarshal(data, &login); err == nil {
+		*a = Author{Login: login, Type: AuthorUser}
+		if strings.TrimSpace(login) == "" {
+			*a = Author{Login: ghostLogin, Type: AuthorGhost}
+		}
+		return nil
+	}
+	type plain Author
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*a = Author(decoded)
+	return nil
+}
+
+// IsBot reports whether the author is a GitHub App or other bot account.
+func (a Author) IsBot() bool {
+	return a.Type == AuthorBot
+}
+
+// actorNode is the GraphQL shape of `author { login __typename }`.
+type actorNode struct {
+	Login    string `json:"login"`
+	Typename string `json:"__typename"`
+}
+
+// newAuthor converts a possibly null GraphQL actor into an Author.
+func newAuthor(actor *actorNode, association string) Author {
+	author := Author{Login: ghostLogin, Type: AuthorGhost, Association: association}
+	if actor == nil || strings.TrimSpace(actor.Login) == "" {
+		return author
+	}
+	author.Login = actor.Login
+	switch actor.Typename {
+	case AuthorBot, AuthorMannequin:
+		author.Type = actor.Typename
+	default:
+		author.Type = AuthorUser
+	}
+	return author
+}
+
+// AuthorFilter selects comments by who wrote them. The zero value allows everyone.
+type AuthorFilter struct {
+	ExcludeBots  bool
+	Associations []string
+}
+
+// ParseAuthorAssociations validates --author-association values, accepting any
+// case and dashes for underscores.
+func ParseAuthorAssociations(values []string) ([]string, error) {
+	parsed := make([]string, 0, len(values))
+	for _, value := range values {
+		v := strings.ToUpper(strings.TrimSpace(value))
+		v = strings.ReplaceAll(v, "-", "_")
+		if v == "" {
+			continue
+		}
+		known := false
+		for _, a := range authorAssociations {
+			if a == v {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid author association %q: must be one of %s", value, strings.ToLower(strings.Join(authorAssociations, ", ")))
+		}
+		parsed = append(parsed, v)
+	}
+	return parsed, nil
+}
+
+// IsZero reports whether the filter allows every author.
+func (f AuthorFilter) IsZero() bool {
+	return !f.ExcludeBots && len(f.Associations) == 0
+}
+
+// Allows reports whether the filter keeps content by the author.
+func (f AuthorFilter) Allows(a Author) bool {
+	if f.ExcludeBots && a.IsBot() {
+		return false
+	}
+	if len(f.Associations) == 0 {
+		return true
+	}
+	for _, association := range f.Associations {
+		if a.Association == association {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterThreadAuthors drops comments the filter rejects and any threads left
+// without comments.
+func FilterThreadAuthors(threads []Thread, f AuthorFilter) []Thread {
+	if f.IsZero() {
+		return threads
+	}
+	filtered := make([]Thread, 0, len(threads))
+	for _, thread := range threads {
+		kept := make([]Comment, 0, len(thread.Comments))
+		for _, c := range thread.Comments {
+			if f.Allows(c.Author) {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		thread.Comments = kept
+		filtered = append(filtered, thread)
+	}
+	return filtered
+}
+
+// FilterReviewAuthors drops reviews the filter rejects.
+func FilterReviewAuthors(reviews []Review, f AuthorFilter) []Review {
+	filtered := make([]Review, 0, len(reviews))
+	for _, r := range reviews {
+		if f.Allows(r.Author) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// FilterIssueCommentAuthors drops conversation comments the filter rejects.
+func FilterIssueCommentAuthors(issueComments []IssueComment, f AuthorFilter) []IssueComment {
+	filtered := make([]IssueComment, 0, len(issueComments))
+	for _, c := range issueComments {
+		if f.Allows(c.Author) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
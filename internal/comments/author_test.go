@@ -0,0 +1,224 @@
+package comments
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+func TestNewAuthor(t *testing.T) {
+	ghost := Author{Login: ghostLogin, Type: AuthorGhost, Association: "NONE"}
+	tests := []struct {
+		name  string
+		actor *actorNode
+		want  Author
+	}{
+		{name: "null", actor: nil, want: ghost},
+		{name: "empty login", actor: &actorNode{Login: " ", Typename: "User"}, want: ghost},
+		{name: "user", actor: &actorNode{Login: "alice", Typename: "User"}, want: Author{Login: "alice", Type: AuthorUser, Association: "NONE"}},
+		{name: "bot", actor: &actorNode{Login: "dependabot", Typename: "Bot"}, want: Author{Login: "dependabot", Type: AuthorBot, Association: "NONE"}},
+		{name: "mannequin", actor: &actorNode{Login: "old-svn", Typename: "Mannequin"}, want: Author{Login: "old-svn", Type: AuthorMannequin, Association: "NONE"}},
+		{name: "other actor", actor: &actorNode{Login: "acme", Typename: "Organization"}, want: Author{Login: "acme", Type: AuthorUser, Association: "NONE"}},
+	}
+	for _, tt := range tests {
+		if got := newAuthor(tt.actor, "NONE"); got != tt.want {
+			t.Errorf("%s: newAuthor = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Author
+	}{
+		{data: `"alice"`, want: Author{Login: "alice", Type: AuthorUser}},
+		{data: `""`, want: Author{Login: ghostLogin, Type: AuthorGhost}},
+		{data: `{"login":"renovate","type":"Bot","association":"CONTRIBUTOR"}`, want: Author{Login: "renovate", Type: AuthorBot, Association: "CONTRIBUTOR"}},
+	}
+	for _, tt := range tests {
+		var got Author
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.data, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("unmarshal %s = %+v, want %+v", tt.data, got, tt.want)
+		}
+	}
+	var author Author
+	if err := json.Unmarshal([]byte(`42`), &author); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
+
+func TestParseAuthorAssociations(t *testing.T) {
+	got, err := ParseAuthorAssociations([]string{"member", " First-Time-Contributor ", "", "OWNER"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := []string{"MEMBER", "FIRST_TIME_CONTRIBUTOR", "OWNER"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("associations = %v, want %v", got, want)
+	}
+
+	if got, err := ParseAuthorAssociations(nil); err != nil || len(got) != 0 {
+		t.Fatalf("parse nil = %v, %v, want none", got, err)
+	}
+
+	_, err = ParseAuthorAssociations([]string{"member", "maintainer"})
+	if err == nil || !strings.Contains(err.Error(), `invalid author association "maintainer"`) {
+		t.Fatalf("err = %v, want the invalid value named", err)
+	}
+}
+
+func TestAuthorFilterAllows(t *testing.T) {
+	member := Author{Login: "alice", Type: AuthorUser, Association: "MEMBER"}
+	bot := Author{Login: "dependabot", Type: AuthorBot, Association: "NONE"}
+	ghost := Author{Login: ghostLogin, Type: AuthorGhost, Association: "NONE"}
+
+	tests := []struct {
+		name   string
+		filter AuthorFilter
+		want   []bool
+	}{
+		{name: "zero", filter: AuthorFilter{}, want: []bool{true, true, true}},
+		{name: "exclude bots", filter: AuthorFilter{ExcludeBots: true}, want: []bool{true, false, true}},
+		{name: "members", filter: AuthorFilter{Associations: []string{"MEMBER", "OWNER"}}, want: []bool{true, false, false}},
+		{name: "none without bots", filter: AuthorFilter{ExcludeBots: true, Associations: []string{"NONE"}}, want: []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		var got []bool
+		for _, author := range []Author{member, bot, ghost} {
+			got = append(got, tt.filter.Allows(author))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: allows member, bot, ghost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterThreadAuthors(t *testing.T) {
+	human := Comment{ID: "c1", Author: Author{Login: "alice", Type: AuthorUser, Association: "MEMBER"}}
+	bot := Comment{ID: "c2", Author: Author{Login: "dependabot", Type: AuthorBot, Association: "NONE"}}
+	ghost := Comment{ID: "c3", Author: Author{Login: ghostLogin, Type: AuthorGhost, Association: "NONE"}}
+	threads := []Thread{
+		{ID: "mixed", Comments: []Comment{bot, human, ghost}},
+		{ID: "bot-only", Comments: []Comment{bot}},
+		{ID: "ghost-only", Comments: []Comment{ghost}},
+	}
+
+	if got := FilterThreadAuthors(threads, AuthorFilter{}); !reflect.DeepEqual(got, threads) {
+		t.Fatalf("zero filter changed the threads: %+v", got)
+	}
+
+	tests := []struct {
+		name   string
+		filter AuthorFilter
+		want   map[string][]string
+	}{
+		{
+			name:   "exclude bots",
+			filter: AuthorFilter{ExcludeBots: true},
+			want:   map[string][]string{"mixed": {"c1", "c3"}, "ghost-only": {"c3"}},
+		},
+		{
+			name:   "members",
+			filter: AuthorFilter{Associations: []string{"MEMBER"}},
+			want:   map[string][]string{"mixed": {"c1"}},
+		},
+	}
+	for _, tt := range tests {
+		got := make(map[string][]string)
+		for _, thread := range FilterThreadAuthors(threads, tt.filter) {
+			for _, c := range thread.Comments {
+				got[thread.ID] = append(got[thread.ID], c.ID)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: comments = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if len(threads[0].Comments) != 3 {
+		t.Fatal("FilterThreadAuthors modified its input")
+	}
+}
+
+func TestFilterReviewAndIssueCommentAuthors(t *testing.T) {
+	filter := AuthorFilter{ExcludeBots: true}
+	reviews := FilterReviewAuthors([]Review{
+		{ID: "r1", Author: Author{Login: "alice", Type: AuthorUser}},
+		{ID: "r2", Author: Author{Login: "ci", Type: AuthorBot}},
+	}, filter)
+	if len(reviews) != 1 || reviews[0].ID != "r1" {
+		t.Errorf("reviews = %+v, want only r1", reviews)
+	}
+	issueComments := FilterIssueCommentAuthors([]IssueComment{
+		{ID: "i1", Author: Author{Login: "ci", Type: AuthorBot}},
+		{ID: "i2", Author: Author{Login: ghostLogin, Type: AuthorGhost}},
+	}, filter)
+	if len(issueComments) != 1 || issueComments[0].ID != "i2" {
+		t.Errorf("issue comments = %+v, want only i2", issueComments)
+	}
+}
+
+func TestExportReportsAuthorObjects(t *testing.T) {
+	backend := ghfake.New("github.com", "octocat")
+	backend.AddPullRequest(&ghfake.PullRequest{
+		Owner:             "acme",
+		Repo:              "widgets",
+		Number:            7,
+		Author:            "alice",
+		AuthorAssociation: "MEMBER",
+		HeadSHA:           "abc1234def",
+		Commits: []ghfake.Commit{
+			{SHA: "abc1234def", Headline: "Add widget", Author: "alice"},
+			{SHA: "def5678abc", Headline: "Bump deps", Author: "renovate[bot]"},
+			{SHA: "0123456789", Headline: "Imported", Author: ""},
+		},
+		Threads: []*ghfake.Thread{
+			{ID: "PRRT_1", Path: "main.go", IsResolved: true, ResolvedBy: "bob"},
+			{ID: "PRRT_2", Path: "main.go", IsResolved: true},
+			{ID: "PRRT_3", Path: "main.go"},
+		},
+	})
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 7, URL: "https://github.com/acme/widgets/pull/7"}
+
+	archive, err := NewService(backend.Client()).Export(pr)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if want := (Author{Login: "alice", Type: AuthorUser, Association: "MEMBER"}); archive.PullRequest.Author != want {
+		t.Errorf("pull request author = %+v, want %+v", archive.PullRequest.Author, want)
+	}
+
+	wantCommits := []Author{
+		{Login: "alice", Type: AuthorUser},
+		{Login: "renovate", Type: AuthorBot},
+		{Login: ghostLogin, Type: AuthorGhost},
+	}
+	for i, want := range wantCommits {
+		if got := archive.Commits[i].Author; got != want {
+			t.Errorf("commit %d author = %+v, want %+v", i, got, want)
+		}
+	}
+	if archive.Commits[1].AuthorName != "renovate[bot]" {
+		t.Errorf("commit author name = %q, want the git name", archive.Commits[1].AuthorName)
+	}
+
+	resolvedBy := make([]*Author, 0, len(archive.Threads))
+	for _, thread := range archive.Threads {
+		resolvedBy = append(resolvedBy, thread.ResolvedBy)
+	}
+	want := []*Author{
+		{Login: "bob", Type: AuthorUser},
+		{Login: ghostLogin, Type: AuthorGhost},
+		nil,
+	}
+	if !reflect.DeepEqual(resolvedBy, want) {
+		t.Errorf("resolved_by = %+v, want bob, ghost and none", resolvedBy)
+	}
+}
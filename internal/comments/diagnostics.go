@@ -0,0 +1,85 @@
+package comments
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diagnosticSummaryLimit = 120
+
+// Diagnostic maps a review thread to a location in the local checkout.
+type Diagnostic struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Outdated  bool
+	ThreadID  string
+	URL       string
+	Author    string
+	Message   string
+}
+
+// Diagnostics converts unresolved threads into editor-style locations. Outdated
+// threads are skipped unless includeOutdated is set, in which case they are
+// placed at their relocated lines when known, otherwise at their original
+// lines, and flagged.
+func Diagnostics(threads []Thread, includeOutdated bool) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, len(threads))
+	for _, thread := range threads {
+		if thread.IsResolved || len(thread.Comments) == 0 {
+			continue
+		}
+		if thread.IsOutdated && !includeOutdated {
+			continue
+		}
+
+		line, start := thread.Line, thread.StartLine
+		if line == nil && thread.Relocation != nil && thread.Relocation.CurrentLine > 0 {
+			current := thread.Relocation.CurrentLine
+			line, start = &current, thread.Relocation.CurrentStartLine
+		}
+		if line == nil {
+			line, start = thread.OriginalLine, thread.OriginalStartLine
+		}
+		end := 1
+		if line != nil {
+			end = *line
+		}
+		begin := end
+		if start != nil && *start > 0 && *start < end {
+			begin = *start
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:      thread.Path,
+			StartLine: begin,
+			EndLine:   end,
+			Outdated:  thread.IsOutdated,
+			ThreadID:  thread.ID,
+			URL:       thread.Comments[0].URL,
+			Author:    thread.Comments[0].Author.Login,
+			Message:   diagnosticMessage(thread),
+		})
+	}
+	return diagnostics
+}
+
+func diagnosticMessage(thread Thread) string {
+	first := thread.Comments[0]
+	summary := strings.Join(strings.Fields(first.Body), " ")
+	if runes := []rune(summary); len(runes) > diagnosticSummaryLimit {
+		summary = string(runes[:diagnosticSummaryLimit-1]) + "…"
+	}
+
+	var b strings.Builder
+	if thread.IsOutdated {
+		b.WriteString("[outdated] ")
+	}
+	fmt.Fprintf(&b, "@%s: %s", first.Author.Login, summary)
+	if replies := len(thread.Comments) - 1; replies == 1 {
+		b.WriteString(" (+1 reply)")
+	} else if replies > 1 {
+		fmt.Fprintf(&b, " (+%d replies)", replies)
+	}
+	return b.String()
+}
@@ -0,0 +1,295 @@
+package comments
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const listReviewsQuery = `query PullRequestReviews($owner: String!, $name: String!, $number: Int!, $first: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviews(first: $first, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          state
+          body
+          submittedAt
+          url
+          authorAssociation
+          author { login __typename }
+        }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+const listIssueCommentsQuery = `query PullRequestIssueComments($owner: String!, $name: String!, $number: Int!, $first: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      comments(first: $first, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          body
+          createdAt
+          url
+          isMinimized
+          minimizedReason
+          authorAssociation
+          author { login __typename }
+        }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+const (
+	defaultFirstReviews       = 100
+	defaultFirstIssueComments = 100
+)
+
+// Timeline entry kinds.
+const (
+	TimelineReviewComment = "review_comment"
+	TimelineReview        = "review"
+	TimelineIssueComment  = "issue_comment"
+)
+
+// Review represents a top-level pull request review and its summary body.
+type Review struct {
+	ID          string `json:"id"`
+	State       string `json:"state"`
+	Body        string `json:"body"`
+	Author      Author `json:"author"`
+	SubmittedAt string `json:"submitted_at,omitempty"`
+	URL         string `json:"url"`
+}
+
+// IssueComment represents a comment on the pull request conversation tab.
+type IssueComment struct {
+	ID              string `json:"id"`
+	Body            string `json:"body"`
+	Author          Author `json:"author"`
+	CreatedAt       string `json:"created_at"`
+	URL             string `json:"url"`
+	IsMinimized     bool   `json:"is_minimized"`
+	MinimizedReason string `json:"minimized_reason,omitempty"`
+}
+
+// TimelineEntry is one item of a unified, time-ordered view of PR discussion.
+type TimelineEntry struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Author    Author `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	URL       string `json:"url"`
+	ThreadID  string `json:"thread_id,omitempty"`
+	Path      string `json:"path,omitempty"`
+	State     string `json:"state,omitempty"`
+}
+
+// ListReviews fetches submitted and pending reviews for a pull request, paging
+// through the reviews connection.
+func (s *Service) ListReviews(pr resolver.Identity) ([]Review, error) {
+	reviews := make([]Review, 0)
+	var after *string
+	for {
+		variables := map[string]interface{}{
+			"owner":  pr.Owner,
+			"name":   pr.Repo,
+			"number": pr.Number,
+			"first":  defaultFirstReviews,
+		}
+		if after != nil {
+			variables["after"] = *after
+		}
+
+		var response struct {
+			Repository *struct {
+				PullRequest *struct {
+					Reviews struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							ID                string     `json:"id"`
+							State             string     `json:"state"`
+							Body              string     `json:"body"`
+							SubmittedAt       *string    `json:"submittedAt"`
+							URL               string     `json:"url"`
+							AuthorAssociation string     `json:"authorAssociation"`
+							Author            *actorNode `json:"author"`
+						} `json:"nodes"`
+					} `json:"reviews"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+
+		if err := s.API.GraphQL(listReviewsQuery, variables, &response); err != nil {
+			return nil, err
+		}
+		if response.Repository == nil || response.Repository.PullRequest == nil {
+			return nil, errors.New("pull request not found or inaccessible")
+		}
+
+		connection := response.Repository.PullRequest.Reviews
+		for _, node := range connection.Nodes {
+			review := Review{
+				ID:     node.ID,
+				State:  node.State,
+				Body:   node.Body,
+				Author: newAuthor(node.Author, node.AuthorAssociation),
+				URL:    node.URL,
+			}
+			if node.SubmittedAt != nil {
+				review.SubmittedAt = *node.SubmittedAt
+			}
+			reviews = append(reviews, review)
+		}
+
+		if !connection.PageInfo.HasNextPage {
+			return reviews, nil
+		}
+		cursor := connection.PageInfo.EndCursor
+		after = &cursor
+	}
+}
+
+// ListIssueComments fetches the pull request's conversation (non-inline)
+// comments, paging through the comments connection.
+func (s *Service) ListIssueComments(pr resolver.Identity) ([]IssueComment, error) {
+	issueComments := make([]IssueComment, 0)
+	var after *string
+	for {
+		variables := map[string]interface{}{
+			"owner":  pr.Owner,
+			"name":   pr.Repo,
+			"number": pr.Number,
+			"first":  defaultFirstIssueComments,
+		}
+		if after != nil {
+			variables["after"] = *after
+		}
+
+		var response struct {
+			Repository *struct {
+				PullRequest *struct {
+					Comments struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							ID                string     `json:"id"`
+							Body              string     `json:"body"`
+							CreatedAt         string     `json:"createdAt"`
+							URL               string     `json:"url"`
+							IsMinimized       bool       `json:"isMinimized"`
+							MinimizedReason   *string    `json:"minimizedReason"`
+							AuthorAssociation string     `json:"authorAssociation"`
+							Author            *actorNode `json:"author"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+
+		if err := s.API.GraphQL(listIssueCommentsQuery, variables, &response); err != nil {
+			return nil, err
+		}
+		if response.Repository == nil || response.Repository.PullRequest == nil {
+			return nil, errors.New("pull request not found or inaccessible")
+		}
+
+		connection := response.Repository.PullRequest.Comments
+		for _, node := range connection.Nodes {
+			issueComments = append(issueComments, IssueComment{
+				ID:              node.ID,
+				Body:            node.Body,
+				Author:          newAuthor(node.Author, node.AuthorAssociation),
+				CreatedAt:       node.CreatedAt,
+				URL:             node.URL,
+				IsMinimized:     node.IsMinimized,
+				MinimizedReason: normalizeMinimizedReason(node.MinimizedReason),
+			})
+		}
+
+		if !connection.PageInfo.HasNextPage {
+			return issueComments, nil
+		}
+		cursor := connection.PageInfo.EndCursor
+		after = &cursor
+	}
+}
+
+// BuildTimeline merges inline comments, reviews and conversation comments into one
+// list ordered by creation time. Pending reviews without a submission time are skipped.
+func BuildTimeline(threads []Thread, reviews []Review, issueComments []IssueComment) []TimelineEntry {
+	entries := make([]TimelineEntry, 0)
+	for _, thread := range threads {
+		for _, c := range thread.Comments {
+			entries = append(entries, TimelineEntry{
+				Type:      TimelineReviewComment,
+				ID:        c.ID,
+				Author:    c.Author,
+				Body:      c.Body,
+				CreatedAt: c.CreatedAt,
+				URL:       c.URL,
+				ThreadID:  thread.ID,
+				Path:      thread.Path,
+			})
+		}
+	}
+	for _, r := range reviews {
+		if r.SubmittedAt == "" {
+			continue
+		}
+		entries = append(entries, TimelineEntry{
+			Type:      TimelineReview,
+			ID:        r.ID,
+			Author:    r.Author,
+			Body:      r.Body,
+			CreatedAt: r.SubmittedAt,
+			URL:       r.URL,
+			State:     r.State,
+		})
+	}
+	for _, c := range issueComments {
+		entries = append(entries, TimelineEntry{
+			Type:      TimelineIssueComment,
+			ID:        c.ID,
+			Author:    c.Author,
+			Body:      c.Body,
+			CreatedAt: c.CreatedAt,
+			URL:       c.URL,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return timestampLess(entries[i].CreatedAt, entries[j].CreatedAt)
+	})
+	return entries
+}
+
+func timestampLess(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ta.Before(tb)
+}
@@ -0,0 +1,81 @@
+package comments
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+func TestListDiscussionPagesThroughAllNodes(t *testing.T) {
+	backend := ghfake.New("github.com", "octocat")
+	pull := &ghfake.PullRequest{Owner: "acme", Repo: "widgets", Number: 7, HeadSHA: "abc1234def"}
+	for i := 0; i < 250; i++ {
+		pull.IssueComments = append(pull.IssueComments, &ghfake.Comment{ID: fmt.Sprintf("IC_%d", i), Author: "carol", Body: "hi"})
+	}
+	for i := 0; i < 120; i++ {
+		pull.Reviews = append(pull.Reviews, &ghfake.Review{ID: fmt.Sprintf("PRR_%d", i), Author: "bob", State: "COMMENTED"})
+	}
+	backend.AddPullRequest(pull)
+	service := NewService(backend.Client())
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 7}
+
+	issueComments, err := service.ListIssueComments(pr)
+	if err != nil {
+		t.Fatalf("list issue comments: %v", err)
+	}
+	if len(issueComments) != 250 || issueComments[0].ID != "IC_0" || issueComments[249].ID != "IC_249" {
+		t.Fatalf("got %d issue comments, want all 250 in order", len(issueComments))
+	}
+
+	reviews, err := service.ListReviews(pr)
+	if err != nil {
+		t.Fatalf("list reviews: %v", err)
+	}
+	if len(reviews) != 120 || reviews[119].ID != "PRR_119" {
+		t.Fatalf("got %d reviews, want all 120 in order", len(reviews))
+	}
+}
+
+func TestBuildTimelineOrdersByCreationTime(t *testing.T) {
+	threads := []Thread{{
+		ID:   "T1",
+		Path: "main.go",
+		Comments: []Comment{
+			{ID: "inline-1", CreatedAt: "2026-01-01T10:00:00Z"},
+			{ID: "inline-2", CreatedAt: "2026-01-01T12:00:00+01:00"},
+		},
+	}}
+	reviews := []Review{
+		{ID: "review", State: "APPROVED", SubmittedAt: "2026-01-01T09:30:00Z"},
+		{ID: "pending", State: "PENDING"},
+	}
+	issueComments := []IssueComment{
+		{ID: "issue-1", CreatedAt: "2026-01-01T10:00:00Z"},
+		{ID: "issue-2", CreatedAt: "2026-01-01T08:00:00Z"},
+	}
+
+	timeline := BuildTimeline(threads, reviews, issueComments)
+	var ids []string
+	for _, entry := range timeline {
+		ids = append(ids, entry.ID)
+	}
+	// inline-2 is 11:00 UTC; equal timestamps keep inline comments before
+	// reviews before conversation comments.
+	want := []string{"issue-2", "review", "inline-1", "issue-1", "inline-2"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("timeline = %v, want %v", ids, want)
+	}
+
+	if timeline[2].Type != TimelineReviewComment || timeline[2].ThreadID != "T1" || timeline[2].Path != "main.go" {
+		t.Fatalf("inline entry = %+v, want thread and path", timeline[2])
+	}
+	if timeline[1].Type != TimelineReview || timeline[1].State != "APPROVED" {
+		t.Fatalf("review entry = %+v, want the approved review", timeline[1])
+	}
+	if timeline[0].Type != TimelineIssueComment {
+		t.Fatalf("first entry = %+v, want a conversation comment", timeline[0])
+	}
+}
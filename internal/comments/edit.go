@@ -0,0 +1,108 @@
+package comments
+
+import (
+	"errors"
+	"strings"
+)
+
+const updateCommentMutation = `mutation UpdatePullRequestReviewComment($input: UpdatePullRequestReviewCommentInput!) {
+  updatePullRequestReviewComment(input: $input) {
+    pullRequestReviewComment {
+      id
+      body
+      createdAt
+      url
+      isMinimized
+      minimizedReason
+      authorAssociation
+      author { login __typename }
+    }
+  }
+}`
+
+const deleteCommentMutation = `mutation DeletePullRequestReviewComment($input: DeletePullRequestReviewCommentInput!) {
+  deletePullRequestReviewComment(input: $input) {
+    pullRequestReview {
+      id
+    }
+  }
+}`
+
+// DeleteResult reports a deleted review comment.
+type DeleteResult struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// Edit replaces the body of an inline review comment.
+func (s *Service) Edit(commentID, body string) (Comment, error) {
+	id := strings.TrimSpace(commentID)
+	if id == "" {
+		return Comment{}, errors.New("comment id is required")
+	}
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return Comment{}, errors.New("body is required")
+	}
+
+	var response struct {
+		UpdatePullRequestReviewComment struct {
+			Comment *struct {
+				ID                string     `json:"id"`
+				Body              string     `json:"body"`
+				CreatedAt         string     `json:"createdAt"`
+				URL               string     `json:"url"`
+				IsMinimized       bool       `json:"isMinimized"`
+				MinimizedReason   *string    `json:"minimizedReason"`
+				AuthorAssociation string     `json:"authorAssociation"`
+				Author            *actorNode `json:"author"`
+			} `json:"pullRequestReviewComment"`
+		} `json:"updatePullRequestReviewComment"`
+	}
+
+	input := map[string]interface{}{
+		"pullRequestReviewCommentId": id,
+		"body":                       body,
+	}
+	if err := s.API.GraphQL(updateCommentMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return Comment{}, err
+	}
+
+	comment := response.UpdatePullRequestReviewComment.Comment
+	if comment == nil {
+		return Comment{}, errors.New("edit response missing comment")
+	}
+
+	return Comment{
+		ID:              comment.ID,
+		Body:            comment.Body,
+		Author:          newAuthor(comment.Author, comment.AuthorAssociation),
+		CreatedAt:       comment.CreatedAt,
+		URL:             comment.URL,
+		IsMinimized:     comment.IsMinimized,
+		MinimizedReason: normalizeMinimizedReason(comment.MinimizedReason),
+	}, nil
+}
+
+// Delete removes an inline review comment. Deleting the only comment of a
+// thread removes the thread.
+func (s *Service) Delete(commentID string) (DeleteResult, error) {
+	id := strings.TrimSpace(commentID)
+	if id == "" {
+		return DeleteResult{}, errors.New("comment id is required")
+	}
+
+	var response struct {
+		DeletePullRequestReviewComment struct {
+			PullRequestReview *struct {
+				ID string `json:"id"`
+			} `json:"pullRequestReview"`
+		} `json:"deletePullRequestReviewComment"`
+	}
+
+	input := map[string]interface{}{"id": id}
+	if err := s.API.GraphQL(deleteCommentMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return DeleteResult{}, err
+	}
+	return DeleteResult{ID: id, Deleted: true}, nil
+}
@@ -0,0 +1,461 @@
+package comments
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// ArchiveSchemaVersion is the version of the export document layout. Bump it
+// whenever a field is removed or changes meaning.
+const ArchiveSchemaVersion = 2
+
+//go:embed archive.schema.json
+var archiveSchema []byte
+
+const exportPullRequestQuery = `query PullRequestExport($owner: String!, $name: String!, $number: Int!, $firstThreads: Int!, $firstComments: Int!, $firstCommits: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      title
+      body
+      state
+      createdAt
+      baseRefName
+      headRefName
+      headRefOid
+      authorAssociation
+      author { login __typename }
+      commits(first: $firstCommits) {
+        nodes {
+          commit {
+            oid
+            messageHeadline
+            committedDate
+            url
+            author {
+              name
+              user { login __typename }
+            }
+          }
+        }
+      }
+      reviewThreads(first: $firstThreads) {
+        nodes {
+          id
+          path
+          line
+          startLine
+          originalLine
+          originalStartLine
+          diffSide
+          startDiffSide
+          isResolved
+          isOutdated
+          resolvedBy { login __typename }
+          comments(first: $firstComments) {
+            nodes {
+              id
+              body
+              createdAt
+              updatedAt
+              url
+              diffHunk
+              authorAssociation
+              author { login __typename }
+              commit { oid }
+              originalCommit { oid }
+              replyTo { id }
+            }
+          }
+        }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+const defaultFirstCommits = 100
+
+// Archive is a portable, versioned snapshot of a pull request's review discussion.
+type Archive struct {
+	SchemaVersion int                `json:"schema_version"`
+	ExportedAt    string             `json:"exported_at"`
+	PullRequest   ArchivePullRequest `json:"pull_request"`
+	Commits       []ArchiveCommit    `json:"commits"`
+	Threads       []ArchiveThread    `json:"threads"`
+	Reviews       []Review           `json:"reviews"`
+	IssueComments []IssueComment     `json:"issue_comments"`
+}
+
+// ArchivePullRequest captures pull request metadata in an archive.
+type ArchivePullRequest struct {
+	Owner     string `json:"owner"`
+	Repo      string `json:"repo"`
+	Host      string `json:"host"`
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Author    Author `json:"author"`
+	State     string `json:"state"`
+	CreatedAt string `json:"created_at"`
+	BaseRef   string `json:"base_ref"`
+	HeadRef   string `json:"head_ref"`
+	HeadSHA   string `json:"head_sha"`
+}
+
+// ArchiveCommit captures one pull request commit in an archive. Author is the
+// GitHub account linked to the commit, or ghost when there is none; AuthorName
+// is the git author name.
+type ArchiveCommit struct {
+	SHA         string `json:"sha"`
+	Headline    string `json:"headline"`
+	Author      Author `json:"author"`
+	AuthorName  string `json:"author_name,omitempty"`
+	CommittedAt string `json:"committed_at"`
+	URL         string `json:"url"`
+}
+
+// ArchiveThread captures a review thread together with its anchoring details.
+type ArchiveThread struct {
+	ID                string           `json:"id"`
+	Path              string           `json:"path"`
+	Line              *int             `json:"line,omitempty"`
+	StartLine         *int             `json:"start_line,omitempty"`
+	OriginalLine      *int             `json:"original_line,omitempty"`
+	OriginalStartLine *int             `json:"original_start_line,omitempty"`
+	DiffSide          string           `json:"diff_side,omitempty"`
+	StartDiffSide     string           `json:"start_diff_side,omitempty"`
+	IsResolved        bool             `json:"is_resolved"`
+	IsOutdated        bool             `json:"is_outdated"`
+	ResolvedBy        *Author          `json:"resolved_by,omitempty"`
+	Comments          []ArchiveComment `json:"comments"`
+}
+
+// ArchiveComment captures one review comment with its diff hunk and commits.
+type ArchiveComment struct {
+	ID                string `json:"id"`
+	Body              string `json:"body"`
+	Author            Author `json:"author"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at,omitempty"`
+	URL               string `json:"url"`
+	DiffHunk          string `json:"diff_hunk"`
+	CommitSHA         string `json:"commit_sha,omitempty"`
+	OriginalCommitSHA string `json:"original_commit_sha,omitempty"`
+	ReplyToID         string `json:"reply_to_id,omitempty"`
+}
+
+// ArchiveSchema returns the JSON Schema describing the export document.
+func ArchiveSchema() []byte {
+	return archiveSchema
+}
+
+// Export fetches everything needed to reconstruct a pull request's review discussion.
+func (s *Service) Export(pr resolver.Identity) (Archive, error) {
+	variables := map[string]interface{}{
+		"owner":         pr.Owner,
+		"name":          pr.Repo,
+		"number":        pr.Number,
+		"firstThreads":  defaultFirstThreads,
+		"firstComments": defaultFirstComments,
+		"firstCommits":  defaultFirstCommits,
+	}
+
+	var response struct {
+		Repository *struct {
+			PullRequest *struct {
+				Title             string     `json:"title"`
+				Body              string     `json:"body"`
+				State             string     `json:"state"`
+				CreatedAt         string     `json:"createdAt"`
+				BaseRefName       string     `json:"baseRefName"`
+				HeadRefName       string     `json:"headRefName"`
+				HeadRefOid        string     `json:"headRefOid"`
+				AuthorAssociation string     `json:"authorAssociation"`
+				Author            *actorNode `json:"author"`
+				Commits           struct {
+					Nodes []struct {
+						Commit struct {
+							Oid             string `json:"oid"`
+							MessageHeadline string `json:"messageHeadline"`
+							CommittedDate   string `json:"committedDate"`
+							URL             string `json:"url"`
+							Author          *struct {
+								Name string     `json:"name"`
+								User *actorNode `json:"user"`
+							} `json:"author"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+				ReviewThreads struct {
+					Nodes []struct {
+						ID                string     `json:"id"`
+						Path              string     `json:"path"`
+						Line              *int       `json:"line"`
+						StartLine         *int       `json:"startLine"`
+						OriginalLine      *int       `json:"originalLine"`
+						OriginalStartLine *int       `json:"originalStartLine"`
+						DiffSide          string     `json:"diffSide"`
+						StartDiffSide     string     `json:"startDiffSide"`
+						IsResolved        bool       `json:"isResolved"`
+						IsOutdated        bool       `json:"isOutdated"`
+						ResolvedBy        *actorNode `json:"resolvedBy"`
+						Comments          struct {
+							Nodes []struct {
+								ID                string     `json:"id"`
+								Body              string     `json:"body"`
+								CreatedAt         string     `json:"createdAt"`
+								UpdatedAt         string     `json:"updatedAt"`
+								URL               string     `json:"url"`
+								DiffHunk          string     `json:"diffHunk"`
+								AuthorAssociation string     `json:"authorAssociation"`
+								Author            *actorNode `json:"author"`
+								Commit            *struct {
+									Oid string `json:"oid"`
+								} `json:"commit"`
+								OriginalCommit *struct {
+									Oid string `json:"oid"`
+								} `json:"originalCommit"`
+								ReplyTo *struct {
+									ID string `json:"id"`
+								} `json:"replyTo"`
+							} `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+
+	if err := s.API.GraphQL(exportPullRequestQuery, variables, &response); err != nil {
+		return Archive{}, err
+	}
+	if response.Repository == nil || response.Repository.PullRequest == nil {
+		return Archive{}, errors.New("pull request not found or inaccessible")
+	}
+	node := response.Repository.PullRequest
+
+	archive := Archive{
+		SchemaVersion: ArchiveSchemaVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		PullRequest: ArchivePullRequest{
+			Owner:     pr.Owner,
+			Repo:      pr.Repo,
+			Host:      pr.Host,
+			Number:    pr.Number,
+			URL:       pr.URL,
+			Title:     node.Title,
+			Body:      node.Body,
+			State:     node.State,
+			CreatedAt: node.CreatedAt,
+			BaseRef:   node.BaseRefName,
+			HeadRef:   node.HeadRefName,
+			HeadSHA:   node.HeadRefOid,
+			Author:    newAuthor(node.Author, node.AuthorAssociation),
+		},
+		Commits: make([]ArchiveCommit, 0, len(node.Commits.Nodes)),
+		Threads: make([]ArchiveThread, 0, len(node.ReviewThreads.Nodes)),
+	}
+	for _, c := range node.Commits.Nodes {
+		commit := ArchiveCommit{
+			SHA:         c.Commit.Oid,
+			Headline:    c.Commit.MessageHeadline,
+			Author:      newAuthor(nil, ""),
+			CommittedAt: c.Commit.CommittedDate,
+			URL:         c.Commit.URL,
+		}
+		if c.Commit.Author != nil {
+			commit.Author = newAuthor(c.Commit.Author.User, "")
+			commit.AuthorName = c.Commit.Author.Name
+		}
+		archive.Commits = append(archive.Commits, commit)
+	}
+
+	for _, t := range node.ReviewThreads.Nodes {
+		thread := ArchiveThread{
+			ID:                t.ID,
+			Path:              t.Path,
+			Line:              t.Line,
+			StartLine:         t.StartLine,
+			OriginalLine:      t.OriginalLine,
+			OriginalStartLine: t.OriginalStartLine,
+			DiffSide:          t.DiffSide,
+			StartDiffSide:     t.StartDiffSide,
+			IsResolved:        t.IsResolved,
+			IsOutdated:        t.IsOutdated,
+			Comments:          make([]ArchiveComment, 0, len(t.Comments.Nodes)),
+		}
+		// A resolved thread whose resolver was deleted reports a null resolvedBy.
+		if t.IsResolved || t.ResolvedBy != nil {
+			resolvedBy := newAuthor(t.ResolvedBy, "")
+			thread.ResolvedBy = &resolvedBy
+		}
+
+		for _, c := range t.Comments.Nodes {
+			comment := ArchiveComment{
+				ID:        c.ID,
+				Body:      c.Body,
+				Author:    newAuthor(c.Author, c.AuthorAssociation),
+				CreatedAt: c.CreatedAt,
+				UpdatedAt: c.UpdatedAt,
+				URL:       c.URL,
+				DiffHunk:  c.DiffHunk,
+			}
+			if c.Commit != nil {
+				comment.CommitSHA = c.Commit.Oid
+			}
+			if c.OriginalCommit != nil {
+				comment.OriginalCommitSHA = c.OriginalCommit.Oid
+			}
+			if c.ReplyTo != nil {
+				comment.ReplyToID = c.ReplyTo.ID
+			}
+			thread.Comments = append(thread.Comments, comment)
+		}
+
+		archive.Threads = append(archive.Threads, thread)
+	}
+
+	reviews, err := s.ListReviews(pr)
+	if err != nil {
+		return Archive{}, err
+	}
+	archive.Reviews = reviews
+
+	issueComments, err := s.ListIssueComments(pr)
+	if err != nil {
+		return Archive{}, err
+	}
+	archive.IssueComments = issueComments
+
+	return archive, nil
+}
+
+// ReadArchive decodes an export document and checks that its version is supported.
+func ReadArchive(r io.Reader) (Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return Archive{}, fmt.Errorf("decode archive: %w", err)
+	}
+	if archive.SchemaVersion == 0 {
+		return Archive{}, errors.New("archive missing schema_version")
+	}
+	if archive.SchemaVersion > ArchiveSchemaVersion {
+		return Archive{}, fmt.Errorf("unsupported archive schema_version %d (max %d)", archive.SchemaVersion, ArchiveSchemaVersion)
+	}
+	return archive, nil
+}
+
+// WriteArchiveMarkdown renders an archive as a Markdown document suitable for
+// committing alongside the code (for example under docs/decisions/).
+func WriteArchiveMarkdown(w io.Writer, archive Archive) error {
+	var b strings.Builder
+	pr := archive.PullRequest
+
+	fmt.Fprintf(&b, "# %s/%s#%d: %s\n\n", pr.Owner, pr.Repo, pr.Number, pr.Title)
+	fmt.Fprintf(&b, "- URL: %s\n", pr.URL)
+	fmt.Fprintf(&b, "- Author: @%s\n", pr.Author.Login)
+	fmt.Fprintf(&b, "- State: %s\n", pr.State)
+	fmt.Fprintf(&b, "- Branches: `%s` ← `%s` (`%s`)\n", pr.BaseRef, pr.HeadRef, shortSHA(pr.HeadSHA))
+	fmt.Fprintf(&b, "- Exported: %s (schema v%d)\n", archive.ExportedAt, archive.SchemaVersion)
+
+	if body := strings.TrimSpace(pr.Body); body != "" {
+		fmt.Fprintf(&b, "\n## Description\n\n%s\n", body)
+	}
+
+	if len(archive.Commits) > 0 {
+		b.WriteString("\n## Commits\n\n")
+		for _, c := range archive.Commits {
+			author := c.AuthorName
+			if author == "" {
+				author = "@" + c.Author.Login
+			}
+			fmt.Fprintf(&b, "- `%s` %s — %s (%s)\n", shortSHA(c.SHA), c.Headline, author, c.CommittedAt)
+		}
+	}
+
+	if len(archive.Reviews) > 0 {
+		b.WriteString("\n## Reviews\n")
+		for _, r := range archive.Reviews {
+			fmt.Fprintf(&b, "\n### @%s — %s (%s)\n", r.Author.Login, r.State, r.SubmittedAt)
+			if body := strings.TrimSpace(r.Body); body != "" {
+				fmt.Fprintf(&b, "\n%s\n", body)
+			}
+		}
+	}
+
+	if len(archive.Threads) > 0 {
+		b.WriteString("\n## Review threads\n")
+		for _, t := range archive.Threads {
+			state := "unresolved"
+			if t.IsResolved {
+				state = "resolved"
+				if t.ResolvedBy != nil {
+					state += " by @" + t.ResolvedBy.Login
+				}
+			}
+			if t.IsOutdated {
+				state += ", outdated"
+			}
+			fmt.Fprintf(&b, "\n### `%s`%s (%s)\n", t.Path, archiveLineLabel(t), state)
+			if len(t.Comments) > 0 && strings.TrimSpace(t.Comments[0].DiffHunk) != "" {
+				fmt.Fprintf(&b, "\n```diff\n%s\n```\n", strings.TrimRight(t.Comments[0].DiffHunk, "\n"))
+			}
+			for _, c := range t.Comments {
+				fmt.Fprintf(&b, "\n**@%s** (%s):\n\n%s\n", c.Author.Login, c.CreatedAt, quoteMarkdown(c.Body))
+			}
+		}
+	}
+
+	if len(archive.IssueComments) > 0 {
+		b.WriteString("\n## Conversation\n")
+		for _, c := range archive.IssueComments {
+			fmt.Fprintf(&b, "\n**@%s** (%s):\n\n%s\n", c.Author.Login, c.CreatedAt, quoteMarkdown(c.Body))
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+	return nil
+}
+
+func archiveLineLabel(t ArchiveThread) string {
+	line, start := t.Line, t.StartLine
+	if line == nil {
+		line, start = t.OriginalLine, t.OriginalStartLine
+	}
+	if line == nil {
+		return ""
+	}
+	if start != nil && *start != *line {
+		return fmt.Sprintf(" lines %d-%d", *start, *line)
+	}
+	return fmt.Sprintf(" line %d", *line)
+}
+
+func quoteMarkdown(body string) string {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+			continue
+		}
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,90 @@
+package comments
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const (
+	filesPageSize = 100
+	maxFilesPages = 30
+)
+
+// PullRequestFiles fetches the changed files of a pull request with their parsed diff hunks.
+// Files without a textual patch (binary or too large) are returned without hunks.
+func (s *Service) PullRequestFiles(pr resolver.Identity) ([]diff.File, error) {
+	path := fmt.Sprintf("repos/%s/%s/pulls/%d/files", pr.Owner, pr.Repo, pr.Number)
+
+	files := make([]diff.File, 0)
+	for page := 1; page <= maxFilesPages; page++ {
+		params := map[string]string{
+			"per_page": strconv.Itoa(filesPageSize),
+			"page":     strconv.Itoa(page),
+		}
+
+		var response []struct {
+			Filename string `json:"filename"`
+			Patch    string `json:"patch"`
+		}
+		if err := s.API.REST("GET", path, params, nil, &response); err != nil {
+			return nil, err
+		}
+
+		for _, f := range response {
+			files = append(files, diff.File{Path: f.Filename, Hunks: diff.Parse(f.Patch)})
+		}
+		if len(response) < filesPageSize {
+			break
+		}
+	}
+
+	return files, nil
+}
+
+func findFile(files []diff.File, path string) (diff.File, bool) {
+	for _, f := range files {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return diff.File{}, false
+}
+
+// FileContent fetches the text of a file at a commit.
+func (s *Service) FileContent(pr resolver.Identity, path, ref string) (string, error) {
+	endpoint := fmt.Sprintf("repos/%s/%s/contents/%s", pr.Owner, pr.Repo, escapePath(path))
+
+	var response struct {
+		Type     string `json:"type"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := s.API.REST("GET", endpoint, map[string]string{"ref": ref}, nil, &response); err != nil {
+		return "", err
+	}
+	if response.Type != "" && response.Type != "file" {
+		return "", fmt.Errorf("%s is a %s, not a file", path, response.Type)
+	}
+	if response.Encoding != "base64" {
+		return "", fmt.Errorf("unsupported encoding %q for %s", response.Encoding, path)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(response.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("decode %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
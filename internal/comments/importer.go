@@ -0,0 +1,254 @@
+package comments
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// ImportedThread describes where an archived thread was (or would be) re-created.
+type ImportedThread struct {
+	SourceThreadID string        `json:"source_thread_id"`
+	SourceURL      string        `json:"source_url"`
+	Path           string        `json:"path"`
+	Line           int           `json:"line"`
+	StartLine      *int          `json:"start_line,omitempty"`
+	Side           string        `json:"side"`
+	Comment        *CreateResult `json:"comment,omitempty"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// importMarkerPrefix starts the hidden marker that records the source thread of
+// an imported comment, so rerunning an import does not duplicate threads.
+const importMarkerPrefix = "<!-- gh-pr-comments:import "
+
+// UnplacedThread reports an archived thread that was not re-created, and why.
+type UnplacedThread struct {
+	SourceThreadID string `json:"source_thread_id"`
+	SourceURL      string `json:"source_url"`
+	Path           string `json:"path"`
+	Reason         string `json:"reason"`
+}
+
+// ImportReport summarizes an import run. Skipped lists threads imported by an
+// earlier run; Warnings reports target threads GitHub withheld, which may hide
+// earlier imports.
+type ImportReport struct {
+	Imported []ImportedThread `json:"imported"`
+	Unplaced []UnplacedThread `json:"unplaced"`
+	Skipped  []UnplacedThread `json:"skipped"`
+	Warnings []Warning        `json:"warnings,omitempty"`
+}
+
+// PlanImport re-anchors each unresolved archived thread to the same path in the
+// target diff by matching the commented lines' content. Resolved threads are
+// ignored, and threads already imported (recognized by a hidden marker in the
+// existing threads' comments) are skipped.
+func PlanImport(archive Archive, files []diff.File, existing []Thread) ImportReport {
+	imported := make(map[string]bool)
+	for _, thread := range existing {
+		for _, c := range thread.Comments {
+			if marker := hiddenMarker(c.Body, importMarkerPrefix); marker != "" {
+				imported[marker] = true
+			}
+		}
+	}
+
+	report := ImportReport{
+		Imported: make([]ImportedThread, 0),
+		Unplaced: make([]UnplacedThread, 0),
+		Skipped:  make([]UnplacedThread, 0),
+	}
+
+	for _, thread := range archive.Threads {
+		if thread.IsResolved || len(thread.Comments) == 0 {
+			continue
+		}
+
+		sourceURL := thread.Comments[0].URL
+		if imported[thread.ID] {
+			report.Skipped = append(report.Skipped, UnplacedThread{
+				SourceThreadID: thread.ID,
+				SourceURL:      sourceURL,
+				Path:           thread.Path,
+				Reason:         "already imported",
+			})
+			continue
+		}
+		placement, reason := placeThread(thread, files)
+		if reason != "" {
+			report.Unplaced = append(report.Unplaced, UnplacedThread{
+				SourceThreadID: thread.ID,
+				SourceURL:      sourceURL,
+				Path:           thread.Path,
+				Reason:         reason,
+			})
+			continue
+		}
+		placement.SourceThreadID = thread.ID
+		placement.SourceURL = sourceURL
+		report.Imported = append(report.Imported, placement)
+	}
+
+	return report
+}
+
+// Import re-creates the unresolved threads of an archive on the target pull request.
+// With dryRun set, only the placement report is computed. A thread that cannot be
+// created is marked with its error and the rest are still imported; the returned
+// error then counts the failures, alongside the full report.
+func (s *Service) Import(pr resolver.Identity, archive Archive, dryRun bool) (ImportReport, error) {
+	files, err := s.PullRequestFiles(pr)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	existing, warnings, err := s.List(pr)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := PlanImport(archive, files, existing)
+	report.Warnings = warnings
+	if dryRun || len(report.Imported) == 0 {
+		return report, nil
+	}
+
+	prID, err := s.PullRequestID(pr)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	threadsByID := make(map[string]ArchiveThread, len(archive.Threads))
+	for _, t := range archive.Threads {
+		threadsByID[t.ID] = t
+	}
+
+	failed := 0
+	for i, placement := range report.Imported {
+		side := placement.Side
+		input := CreateInput{
+			Path:      placement.Path,
+			Line:      placement.Line,
+			Side:      side,
+			StartLine: placement.StartLine,
+			Body:      importBody(threadsByID[placement.SourceThreadID], archive.PullRequest),
+		}
+		if placement.StartLine != nil {
+			input.StartSide = &side
+		}
+
+		created, err := s.CreateWithPR(prID, input)
+		if err != nil {
+			report.Imported[i].Error = err.Error()
+			failed++
+			continue
+		}
+		report.Imported[i].Comment = &created
+	}
+
+	if failed > 0 {
+		return report, fmt.Errorf("%d of %d threads failed to import", failed, len(report.Imported))
+	}
+	return report, nil
+}
+
+func placeThread(thread ArchiveThread, files []diff.File) (ImportedThread, string) {
+	side := strings.ToUpper(thread.DiffSide)
+	if side == "" {
+		side = "RIGHT"
+	}
+
+	span := 1
+	if thread.OriginalStartLine != nil && thread.OriginalLine != nil && *thread.OriginalLine > *thread.OriginalStartLine {
+		span = *thread.OriginalLine - *thread.OriginalStartLine + 1
+	}
+
+	anchor := diff.TailLines(thread.Comments[0].DiffHunk, side, span)
+	if len(anchor) == 0 {
+		return ImportedThread{}, "thread has no diff hunk to match against"
+	}
+
+	file, ok := findFile(files, thread.Path)
+	if !ok {
+		return ImportedThread{}, "path is not changed in the target pull request"
+	}
+
+	lines := diff.SideLines(file.Hunks, side)
+	candidates := matchWindow(lines, anchor, side)
+	if len(candidates) == 0 {
+		return ImportedThread{}, "commented code not found in the target diff"
+	}
+
+	original := 0
+	if thread.OriginalLine != nil {
+		original = *thread.OriginalLine
+	}
+	best := closestTo(candidates, original)
+
+	placement := ImportedThread{
+		Path: thread.Path,
+		Line: best + len(anchor) - 1,
+		Side: side,
+	}
+	if len(anchor) > 1 {
+		start := best
+		placement.StartLine = &start
+	}
+	return placement, ""
+}
+
+// matchWindow returns the starting line numbers where the anchor lines appear as
+// consecutive lines on the given side, ignoring surrounding whitespace.
+func matchWindow(lines []diff.Line, anchor []diff.Line, side string) []int {
+	starts := make([]int, 0)
+	for i := 0; i+len(anchor) <= len(lines); i++ {
+		matched := true
+		for j, a := range anchor {
+			l := lines[i+j]
+			if strings.TrimSpace(l.Text) != strings.TrimSpace(a.Text) {
+				matched = false
+				break
+			}
+			if j > 0 && diff.LineNumber(l, side) != diff.LineNumber(lines[i+j-1], side)+1 {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			starts = append(starts, diff.LineNumber(lines[i], side))
+		}
+	}
+	return starts
+}
+
+func closestTo(candidates []int, target int) int {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if absInt(c-target) < absInt(best-target) {
+			best = c
+		}
+	}
+	return best
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func importBody(thread ArchiveThread, source ArchivePullRequest) string {
+	var b strings.Builder
+	for i, c := range thread.Comments {
+		verb := "replied"
+		if i == 0 {
+			verb = "commented"
+		}
+		fmt.Fprintf(&b, "> **@%s** [%s](%s) on %s:\n>\n%s\n\n", c.Author.Login, verb, c.URL, c.CreatedAt, quoteMarkdown(c.Body))
+	}
+	fmt.Fprintf(&b, "_Imported from %s/%s#%d (%s)._\n\n%s%s -->", source.Owner, source.Repo, source.Number, source.URL, importMarkerPrefix, thread.ID)
+	return b.String()
+}
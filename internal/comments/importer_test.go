@@ -0,0 +1,188 @@
+package comments
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// importTarget adds a comment block above the import and changes a line of
+// util.go, so archived threads move down in main.go.
+var importTarget = []diff.File{
+	{Path: "main.go", Hunks: diff.Parse("@@ -1,4 +1,6 @@\n package main\n+\n+// Widget helpers.\n import \"fmt\"\n \n func main() {}")},
+	{Path: "util.go", Hunks: diff.Parse("@@ -1,2 +1,2 @@\n package util\n-var x = 1\n+var x = 2")},
+}
+
+func archiveThread(id, path, side, hunk string, startLine *int, line int) ArchiveThread {
+	return ArchiveThread{
+		ID:                id,
+		Path:              path,
+		OriginalLine:      &line,
+		OriginalStartLine: startLine,
+		DiffSide:          side,
+		Comments: []ArchiveComment{{
+			ID:       id + "_c",
+			Body:     "Why?",
+			Author:   Author{Login: "bob", Type: AuthorUser},
+			URL:      "https://github.com/acme/widgets/pull/7#discussion_" + id,
+			DiffHunk: hunk,
+		}},
+	}
+}
+
+func TestPlanImportReanchorsThreads(t *testing.T) {
+	const hunk = "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"\n \n func main() {}"
+	tests := []struct {
+		name      string
+		thread    ArchiveThread
+		wantLine  int
+		wantStart int
+		wantSide  string
+		reason    string
+	}{
+		{
+			name:     "line moved down by added lines",
+			thread:   archiveThread("T1", "main.go", "RIGHT", "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"", nil, 2),
+			wantLine: 4,
+			wantSide: "RIGHT",
+		},
+		{
+			name:      "multi-line range",
+			thread:    archiveThread("T2", "main.go", "RIGHT", hunk, intPtr(3), 4),
+			wantLine:  6,
+			wantStart: 5,
+			wantSide:  "RIGHT",
+		},
+		{
+			name:     "closest of several matches",
+			thread:   archiveThread("T3", "main.go", "RIGHT", "@@ -1,2 +1,3 @@\n package main\n+import \"fmt\"\n ", nil, 3),
+			wantLine: 2,
+			wantSide: "RIGHT",
+		},
+		{
+			name:     "closest of several matches further down",
+			thread:   archiveThread("T4", "main.go", "RIGHT", "@@ -1,2 +1,3 @@\n package main\n+import \"fmt\"\n ", nil, 5),
+			wantLine: 5,
+			wantSide: "RIGHT",
+		},
+		{
+			name:     "removed line on the left side",
+			thread:   archiveThread("T5", "util.go", "LEFT", "@@ -1,3 +1,2 @@\n package util\n \n-var x = 1", nil, 3),
+			wantLine: 2,
+			wantSide: "LEFT",
+		},
+		{
+			name:   "range no longer consecutive",
+			thread: archiveThread("T6", "main.go", "RIGHT", "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"", intPtr(1), 2),
+			reason: "commented code not found in the target diff",
+		},
+		{
+			name:   "path not in the target diff",
+			thread: archiveThread("T7", "other.go", "RIGHT", "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"", nil, 2),
+			reason: "path is not changed in the target pull request",
+		},
+		{
+			name:   "no diff hunk",
+			thread: archiveThread("T8", "main.go", "RIGHT", "", nil, 2),
+			reason: "thread has no diff hunk to match against",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := PlanImport(Archive{Threads: []ArchiveThread{tt.thread}}, importTarget, nil)
+			if tt.reason != "" {
+				if len(report.Unplaced) != 1 || report.Unplaced[0].Reason != tt.reason {
+					t.Fatalf("unplaced = %+v, want reason %q", report.Unplaced, tt.reason)
+				}
+				return
+			}
+			if len(report.Imported) != 1 {
+				t.Fatalf("imported = %+v, unplaced = %+v, want one placement", report.Imported, report.Unplaced)
+			}
+			placed := report.Imported[0]
+			if placed.Line != tt.wantLine || placed.Side != tt.wantSide {
+				t.Fatalf("placed at %s %d, want %s %d", placed.Side, placed.Line, tt.wantSide, tt.wantLine)
+			}
+			start := 0
+			if placed.StartLine != nil {
+				start = *placed.StartLine
+			}
+			if start != tt.wantStart {
+				t.Fatalf("start_line = %d, want %d", start, tt.wantStart)
+			}
+			if placed.SourceThreadID != tt.thread.ID || placed.SourceURL != tt.thread.Comments[0].URL {
+				t.Fatalf("source = %s %s", placed.SourceThreadID, placed.SourceURL)
+			}
+		})
+	}
+}
+
+func TestPlanImportSkipsResolvedAndImportedThreads(t *testing.T) {
+	const hunk = "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\""
+	resolved := archiveThread("T1", "main.go", "RIGHT", hunk, nil, 2)
+	resolved.IsResolved = true
+	archive := Archive{Threads: []ArchiveThread{
+		resolved,
+		archiveThread("T2", "main.go", "RIGHT", hunk, nil, 2),
+		archiveThread("T3", "main.go", "RIGHT", hunk, nil, 2),
+	}}
+	existing := []Thread{{Comments: []Comment{{Body: importBody(archive.Threads[1], ArchivePullRequest{})}}}}
+
+	report := PlanImport(archive, importTarget, existing)
+	if len(report.Skipped) != 1 || report.Skipped[0].SourceThreadID != "T2" || report.Skipped[0].Reason != "already imported" {
+		t.Fatalf("skipped = %+v, want T2", report.Skipped)
+	}
+	if len(report.Imported) != 1 || report.Imported[0].SourceThreadID != "T3" {
+		t.Fatalf("imported = %+v, want only T3", report.Imported)
+	}
+}
+
+func TestImportIsIdempotentAndResolvesPullRequestOnce(t *testing.T) {
+	backend := ghfake.New("github.com", "octocat")
+	backend.AddPullRequest(&ghfake.PullRequest{
+		Owner:   "acme",
+		Repo:    "widgets",
+		Number:  8,
+		HeadSHA: "abc1234def",
+		Files:   []ghfake.File{{Filename: "main.go", Patch: testPatch}},
+	})
+	api := &countingAPI{API: backend}
+	service := NewService(api)
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 8}
+	archive := Archive{
+		PullRequest: ArchivePullRequest{Owner: "acme", Repo: "widgets", Number: 7, URL: "https://github.com/acme/widgets/pull/7"},
+		Threads: []ArchiveThread{
+			archiveThread("T1", "main.go", "RIGHT", testPatch, nil, 4),
+			archiveThread("T2", "main.go", "RIGHT", "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"", nil, 2),
+		},
+	}
+
+	report, err := service.Import(pr, archive, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(report.Imported) != 2 || report.Imported[0].Comment == nil || report.Imported[1].Comment == nil {
+		t.Fatalf("imported = %+v, want both threads created", report.Imported)
+	}
+	if !strings.Contains(report.Imported[0].Comment.Body, importMarkerPrefix+"T1 -->") {
+		t.Fatalf("body = %q, want the import marker", report.Imported[0].Comment.Body)
+	}
+	if lookups := api.calls["PullRequestNode"]; lookups != 1 {
+		t.Fatalf("looked up the pull request %d times, want once", lookups)
+	}
+
+	again, err := service.Import(pr, archive, false)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if len(again.Imported) != 0 || len(again.Skipped) != 2 {
+		t.Fatalf("second import = %+v, want both threads skipped", again)
+	}
+	if threads := backend.PullRequests[0].Threads; len(threads) != 2 {
+		t.Fatalf("target has %d threads, want 2", len(threads))
+	}
+}
@@ -0,0 +1,131 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// LineMatch is a location in the head version of a file where a snippet matched.
+type LineMatch struct {
+	StartLine int    `json:"start_line"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+}
+
+// MatchLines searches the head side of a file's diff hunks for a literal
+// snippet, or a regular expression when regex is set. Matches may span lines
+// but never a gap between hunks.
+func (s *Service) MatchLines(pr resolver.Identity, path, pattern string, regex bool) ([]LineMatch, error) {
+	if pattern == "" {
+		return nil, errors.New("match pattern is required")
+	}
+	var re *regexp.Regexp
+	if regex {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match regular expression: %w", err)
+		}
+		re = compiled
+	} else {
+		re = regexp.MustCompile(regexp.QuoteMeta(pattern))
+	}
+
+	files, err := s.PullRequestFiles(pr)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := findFile(files, path)
+	if !ok {
+		return nil, fmt.Errorf("%s is not changed in pull request #%d", path, pr.Number)
+	}
+
+	return matchRuns(diff.SideLines(file.Hunks, "RIGHT"), re), nil
+}
+
+// matchRuns matches re against each run of consecutive head-side lines.
+func matchRuns(lines []diff.Line, re *regexp.Regexp) []LineMatch {
+	matches := make([]LineMatch, 0)
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && lines[end].NewLine == lines[end-1].NewLine+1 {
+			end++
+		}
+		run := lines[start:end]
+		start = end
+
+		texts := make([]string, len(run))
+		offsets := make([]int, len(run))
+		offset := 0
+		for i, l := range run {
+			texts[i] = l.Text
+			offsets[i] = offset
+			offset += len(l.Text) + 1
+		}
+		text := strings.Join(texts, "\n")
+
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			first, last := lineAt(offsets, loc[0]), lineAt(offsets, loc[0])
+			if loc[1] > loc[0] {
+				last = lineAt(offsets, loc[1]-1)
+			}
+			// Matches on the same lines (including zero-width ones such as `^$`)
+			// would anchor the comment identically, so keep only the first.
+			if n := len(matches); n > 0 && matches[n-1].StartLine == run[first].NewLine && matches[n-1].Line == run[last].NewLine {
+				continue
+			}
+			matches = append(matches, LineMatch{
+				StartLine: run[first].NewLine,
+				Line:      run[last].NewLine,
+				Text:      strings.Join(texts[first:last+1], "\n"),
+			})
+		}
+	}
+	return matches
+}
+
+// lineAt returns the index of the line containing byte offset pos.
+func lineAt(offsets []int, pos int) int {
+	i := 0
+	for i+1 < len(offsets) && offsets[i+1] <= pos {
+		i++
+	}
+	return i
+}
+
+// SelectMatch picks the occurrence-th match (1-based). With occurrence zero the
+// match must be unique; otherwise the error lists every candidate.
+func SelectMatch(path string, matches []LineMatch, occurrence int) (LineMatch, error) {
+	if len(matches) == 0 {
+		return LineMatch{}, fmt.Errorf("no match in the diff of %s", path)
+	}
+	if occurrence > 0 {
+		if occurrence > len(matches) {
+			return LineMatch{}, fmt.Errorf("--occurrence %d out of range: %d matches in %s%s", occurrence, len(matches), path, describeMatches(path, matches))
+		}
+		return matches[occurrence-1], nil
+	}
+	if len(matches) > 1 {
+		return LineMatch{}, fmt.Errorf("ambiguous match: %d matches in %s; pick one with --occurrence%s", len(matches), path, describeMatches(path, matches))
+	}
+	return matches[0], nil
+}
+
+func describeMatches(path string, matches []LineMatch) string {
+	var b strings.Builder
+	for i, m := range matches {
+		location := fmt.Sprintf("%s:%d", path, m.StartLine)
+		if m.Line > m.StartLine {
+			location = fmt.Sprintf("%s:%d-%d", path, m.StartLine, m.Line)
+		}
+		fmt.Fprintf(&b, "\n  %d. %s", i+1, location)
+		if first := strings.TrimSpace(strings.SplitN(m.Text, "\n", 2)[0]); first != "" {
+			b.WriteString(": " + first)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package comments
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+)
+
+// matchPatch has two hunks: head lines 1-4 and 11-13.
+const matchPatch = "@@ -1,3 +1,4 @@\n package main\n+\n import \"fmt\"\n \n" +
+	"@@ -10,2 +11,3 @@\n func a() {\n+\tfmt.Println(\"a\")\n }"
+
+func TestMatchRuns(t *testing.T) {
+	lines := diff.SideLines(diff.Parse(matchPatch), "RIGHT")
+
+	tests := []struct {
+		name    string
+		pattern string
+		regex   bool
+		want    []LineMatch
+	}{
+		{
+			name:    "literal in both hunks",
+			pattern: "fmt",
+			want: []LineMatch{
+				{StartLine: 3, Line: 3, Text: `import "fmt"`},
+				{StartLine: 12, Line: 12, Text: "\tfmt.Println(\"a\")"},
+			},
+		},
+		{
+			name:    "literal metacharacters",
+			pattern: "a()",
+			want:    []LineMatch{{StartLine: 11, Line: 11, Text: "func a() {"}},
+		},
+		{
+			name:    "same-line matches are deduplicated",
+			pattern: "t",
+			want: []LineMatch{
+				{StartLine: 3, Line: 3, Text: `import "fmt"`},
+				{StartLine: 12, Line: 12, Text: "\tfmt.Println(\"a\")"},
+			},
+		},
+		{
+			name:    "regex spanning lines",
+			pattern: `main\n\nimport`,
+			regex:   true,
+			want:    []LineMatch{{StartLine: 1, Line: 3, Text: "package main\n\nimport \"fmt\""}},
+		},
+		{
+			name:    "trailing newline stays on its line",
+			pattern: `main\n`,
+			regex:   true,
+			want:    []LineMatch{{StartLine: 1, Line: 1, Text: "package main"}},
+		},
+		{
+			name:    "zero-width matches",
+			pattern: `(?m)^$`,
+			regex:   true,
+			want: []LineMatch{
+				{StartLine: 2, Line: 2, Text: ""},
+				{StartLine: 4, Line: 4, Text: ""},
+			},
+		},
+		{
+			name:    "no match across the gap between hunks",
+			pattern: "\"fmt\"\n\nfunc a",
+		},
+		{
+			name:    "regex across the gap between hunks",
+			pattern: `(?s)import.*func`,
+			regex:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		pattern := regexp.QuoteMeta(tt.pattern)
+		if tt.regex {
+			pattern = tt.pattern
+		}
+		got := matchRuns(lines, regexp.MustCompile(pattern))
+		want := tt.want
+		if want == nil {
+			want = []LineMatch{}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: matchRuns(%q) = %+v, want %+v", tt.name, tt.pattern, got, want)
+		}
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	offsets := []int{0, 5, 6, 20}
+	tests := []struct{ pos, want int }{
+		{0, 0}, {4, 0}, {5, 1}, {6, 2}, {19, 2}, {20, 3}, {100, 3},
+	}
+	for _, tt := range tests {
+		if got := lineAt(offsets, tt.pos); got != tt.want {
+			t.Errorf("lineAt(%d) = %d, want %d", tt.pos, got, tt.want)
+		}
+	}
+	if got := lineAt([]int{0}, 7); got != 0 {
+		t.Errorf("lineAt on a single line = %d, want 0", got)
+	}
+}
+
+func TestSelectMatch(t *testing.T) {
+	matches := []LineMatch{
+		{StartLine: 3, Line: 3, Text: `  import "fmt"`},
+		{StartLine: 11, Line: 12, Text: "func a() {\n\tfmt.Println(\"a\")"},
+		{StartLine: 4, Line: 4, Text: ""},
+	}
+
+	tests := []struct {
+		name       string
+		matches    []LineMatch
+		occurrence int
+		want       LineMatch
+		errParts   []string
+		errSuffix  string
+	}{
+		{name: "unique", matches: matches[:1], want: matches[0]},
+		{name: "occurrence picks", matches: matches, occurrence: 2, want: matches[1]},
+		{name: "occurrence on a unique match", matches: matches[:1], occurrence: 1, want: matches[0]},
+		{name: "no matches", errParts: []string{"no match in the diff of main.go"}},
+		{name: "no matches with occurrence", occurrence: 1, errParts: []string{"no match in the diff of main.go"}},
+		{
+			name:    "ambiguous",
+			matches: matches,
+			errParts: []string{
+				"ambiguous match: 3 matches in main.go; pick one with --occurrence",
+				"\n  1. main.go:3: import \"fmt\"",
+				"\n  2. main.go:11-12: func a() {",
+			},
+			// A match on an empty line is listed without text.
+			errSuffix: "\n  3. main.go:4",
+		},
+		{
+			name:       "occurrence out of range",
+			matches:    matches[:2],
+			occurrence: 3,
+			errParts:   []string{"--occurrence 3 out of range: 2 matches in main.go", "\n  2. main.go:11-12"},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := SelectMatch("main.go", tt.matches, tt.occurrence)
+
+		if len(tt.errParts) > 0 {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.name, got)
+				continue
+			}
+			for _, part := range tt.errParts {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("%s: error %q does not contain %q", tt.name, err, part)
+				}
+			}
+			if !strings.HasSuffix(err.Error(), tt.errSuffix) {
+				t.Errorf("%s: error %q does not end with %q", tt.name, err, tt.errSuffix)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SelectMatch() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
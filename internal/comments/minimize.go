@@ -0,0 +1,160 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const minimizeCommentMutation = `mutation MinimizeComment($input: MinimizeCommentInput!) {
+  minimizeComment(input: $input) {
+    minimizedComment {
+      isMinimized
+      minimizedReason
+    }
+  }
+}`
+
+const unminimizeCommentMutation = `mutation UnminimizeComment($input: UnminimizeCommentInput!) {
+  unminimizeComment(input: $input) {
+    unminimizedComment {
+      isMinimized
+      minimizedReason
+    }
+  }
+}`
+
+// MinimizeResult reports the visibility state of a comment after a hide/unhide
+// operation. Error is set instead when the comment could not be updated.
+type MinimizeResult struct {
+	ID              string `json:"id"`
+	IsMinimized     bool   `json:"is_minimized"`
+	MinimizedReason string `json:"minimized_reason,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Minimize hides a comment using the given classifier, mirroring the web UI's "Hide" menu.
+func (s *Service) Minimize(commentID, reason string) (MinimizeResult, error) {
+	id := strings.TrimSpace(commentID)
+	if id == "" {
+		return MinimizeResult{}, errors.New("comment id is required")
+	}
+	classifier, err := normalizeClassifier(reason)
+	if err != nil {
+		return MinimizeResult{}, err
+	}
+
+	var response struct {
+		MinimizeComment struct {
+			MinimizedComment *struct {
+				IsMinimized     bool    `json:"isMinimized"`
+				MinimizedReason *string `json:"minimizedReason"`
+			} `json:"minimizedComment"`
+		} `json:"minimizeComment"`
+	}
+
+	input := map[string]interface{}{
+		"subjectId":  id,
+		"classifier": classifier,
+	}
+	if err := s.API.GraphQL(minimizeCommentMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return MinimizeResult{}, err
+	}
+
+	comment := response.MinimizeComment.MinimizedComment
+	if comment == nil {
+		return MinimizeResult{}, errors.New("minimize response missing comment")
+	}
+
+	return MinimizeResult{
+		ID:              id,
+		IsMinimized:     comment.IsMinimized,
+		MinimizedReason: normalizeMinimizedReason(comment.MinimizedReason),
+	}, nil
+}
+
+// Unminimize restores a previously hidden comment.
+func (s *Service) Unminimize(commentID string) (MinimizeResult, error) {
+	id := strings.TrimSpace(commentID)
+	if id == "" {
+		return MinimizeResult{}, errors.New("comment id is required")
+	}
+
+	var response struct {
+		UnminimizeComment struct {
+			UnminimizedComment *struct {
+				IsMinimized     bool    `json:"isMinimized"`
+				MinimizedReason *string `json:"minimizedReason"`
+			} `json:"unminimizedComment"`
+		} `json:"unminimizeComment"`
+	}
+
+	input := map[string]interface{}{"subjectId": id}
+	if err := s.API.GraphQL(unminimizeCommentMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return MinimizeResult{}, err
+	}
+
+	comment := response.UnminimizeComment.UnminimizedComment
+	if comment == nil {
+		return MinimizeResult{}, errors.New("unminimize response missing comment")
+	}
+
+	return MinimizeResult{
+		ID:              id,
+		IsMinimized:     comment.IsMinimized,
+		MinimizedReason: normalizeMinimizedReason(comment.MinimizedReason),
+	}, nil
+}
+
+// FilterMinimized drops hidden comments and any threads left without visible comments.
+func FilterMinimized(threads []Thread) []Thread {
+	filtered := make([]Thread, 0, len(threads))
+	for _, thread := range threads {
+		visible := make([]Comment, 0, len(thread.Comments))
+		for _, c := range thread.Comments {
+			if !c.IsMinimized {
+				visible = append(visible, c)
+			}
+		}
+		if len(visible) == 0 {
+			continue
+		}
+		thread.Comments = visible
+		filtered = append(filtered, thread)
+	}
+	return filtered
+}
+
+// FilterMinimizedIssueComments drops hidden conversation comments.
+func FilterMinimizedIssueComments(issueComments []IssueComment) []IssueComment {
+	filtered := make([]IssueComment, 0, len(issueComments))
+	for _, c := range issueComments {
+		if !c.IsMinimized {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func normalizeClassifier(reason string) (string, error) {
+	r := strings.ToUpper(strings.TrimSpace(reason))
+	r = strings.ReplaceAll(r, "-", "_")
+	switch r {
+	case "OUTDATED", "RESOLVED", "DUPLICATE", "OFF_TOPIC", "SPAM", "ABUSE":
+		return r, nil
+	case "":
+		return "", errors.New("reason is required")
+	default:
+		return "", fmt.Errorf("invalid reason %q: must be outdated, resolved, duplicate, off-topic, spam, or abuse", reason)
+	}
+}
+
+// normalizeMinimizedReason lowercases GitHub's reason (e.g. "OUTDATED" or "outdated")
+// so output matches the values accepted by --reason.
+func normalizeMinimizedReason(reason *string) string {
+	if reason == nil {
+		return ""
+	}
+	r := strings.ToLower(strings.TrimSpace(*reason))
+	return strings.ReplaceAll(r, "_", "-")
+}
@@ -0,0 +1,75 @@
+package comments
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterMinimized(t *testing.T) {
+	visible := Comment{ID: "c1"}
+	hidden := Comment{ID: "c2", IsMinimized: true, MinimizedReason: "outdated"}
+	threads := []Thread{
+		{ID: "mixed", Comments: []Comment{hidden, visible}},
+		{ID: "all-hidden", Comments: []Comment{hidden}},
+		{ID: "all-visible", Comments: []Comment{visible}},
+	}
+
+	filtered := FilterMinimized(threads)
+	var ids []string
+	for _, thread := range filtered {
+		ids = append(ids, thread.ID)
+		for _, c := range thread.Comments {
+			if c.IsMinimized {
+				t.Fatalf("thread %s kept hidden comment %s", thread.ID, c.ID)
+			}
+		}
+	}
+	if want := []string{"mixed", "all-visible"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("threads = %v, want %v", ids, want)
+	}
+	if len(threads[0].Comments) != 2 {
+		t.Fatal("FilterMinimized modified its input")
+	}
+}
+
+func TestFilterMinimizedIssueComments(t *testing.T) {
+	filtered := FilterMinimizedIssueComments([]IssueComment{{ID: "a"}, {ID: "b", IsMinimized: true}})
+	if len(filtered) != 1 || filtered[0].ID != "a" {
+		t.Fatalf("filtered = %+v, want only a", filtered)
+	}
+}
+
+func TestNormalizeClassifier(t *testing.T) {
+	tests := []struct {
+		reason  string
+		want    string
+		wantErr bool
+	}{
+		{reason: "outdated", want: "OUTDATED"},
+		{reason: " Off-Topic ", want: "OFF_TOPIC"},
+		{reason: "off_topic", want: "OFF_TOPIC"},
+		{reason: "SPAM", want: "SPAM"},
+		{reason: "", wantErr: true},
+		{reason: "rude", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeClassifier(tt.reason)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeClassifier(%q) error = %v, wantErr %v", tt.reason, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeClassifier(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMinimizedReason(t *testing.T) {
+	reason := "OFF_TOPIC"
+	if got := normalizeMinimizedReason(&reason); got != "off-topic" {
+		t.Fatalf("normalizeMinimizedReason = %q, want off-topic", got)
+	}
+	if got := normalizeMinimizedReason(nil); got != "" {
+		t.Fatalf("normalizeMinimizedReason(nil) = %q, want empty", got)
+	}
+}
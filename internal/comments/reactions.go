@@ -0,0 +1,97 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const addReactionMutation = `mutation AddReaction($input: AddReactionInput!) {
+  addReaction(input: $input) {
+    reaction {
+      content
+    }
+    subject {
+      id
+    }
+  }
+}`
+
+// reactionContents maps accepted spellings to GitHub's ReactionContent values.
+var reactionContents = map[string]string{
+	"+1":          "THUMBS_UP",
+	"thumbs_up":   "THUMBS_UP",
+	"-1":          "THUMBS_DOWN",
+	"thumbs_down": "THUMBS_DOWN",
+	"laugh":       "LAUGH",
+	"hooray":      "HOORAY",
+	"confused":    "CONFUSED",
+	"heart":       "HEART",
+	"rocket":      "ROCKET",
+	"eyes":        "EYES",
+}
+
+// ReactionNames lists the reactions accepted by React, in GitHub's display order.
+var ReactionNames = []string{"+1", "-1", "laugh", "hooray", "confused", "heart", "rocket", "eyes"}
+
+// ReactionResult reports a reaction added to a comment.
+type ReactionResult struct {
+	SubjectID string `json:"subject_id"`
+	Content   string `json:"content"`
+}
+
+// React adds an emoji reaction to a comment.
+func (s *Service) React(subjectID, reaction string) (ReactionResult, error) {
+	id := strings.TrimSpace(subjectID)
+	if id == "" {
+		return ReactionResult{}, errors.New("comment id is required")
+	}
+	content, err := normalizeReaction(reaction)
+	if err != nil {
+		return ReactionResult{}, err
+	}
+
+	var response struct {
+		AddReaction struct {
+			Reaction *struct {
+				Content string `json:"content"`
+			} `json:"reaction"`
+			Subject *struct {
+				ID string `json:"id"`
+			} `json:"subject"`
+		} `json:"addReaction"`
+	}
+
+	input := map[string]interface{}{
+		"subjectId": id,
+		"content":   content,
+	}
+	if err := s.API.GraphQL(addReactionMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return ReactionResult{}, err
+	}
+
+	if response.AddReaction.Reaction == nil {
+		return ReactionResult{}, errors.New("reaction response missing reaction")
+	}
+	result := ReactionResult{SubjectID: id, Content: strings.ToLower(response.AddReaction.Reaction.Content)}
+	if response.AddReaction.Subject != nil && response.AddReaction.Subject.ID != "" {
+		result.SubjectID = response.AddReaction.Subject.ID
+	}
+	return result, nil
+}
+
+func normalizeReaction(reaction string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(reaction))
+	key = strings.Trim(key, ":")
+	if content, ok := reactionContents[key]; ok {
+		return content, nil
+	}
+	if content := strings.ToUpper(key); content != "" {
+		for _, known := range reactionContents {
+			if known == content {
+				return content, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("invalid reaction %q: must be one of %s", reaction, strings.Join(ReactionNames, ", "))
+}
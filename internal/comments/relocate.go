@@ -0,0 +1,275 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// Relocation match kinds.
+const (
+	MatchExact = "exact"
+	MatchFuzzy = "fuzzy"
+	MatchNone  = "none"
+)
+
+// minFuzzyScore is the lowest average line similarity accepted as a fuzzy match.
+const minFuzzyScore = 0.6
+
+// Relocation reports where the code of an outdated thread is in the current head.
+type Relocation struct {
+	CurrentLine      int           `json:"current_line,omitempty"`
+	CurrentStartLine *int          `json:"current_start_line,omitempty"`
+	Confidence       float64       `json:"confidence"`
+	Match            string        `json:"match"`
+	Reason           string        `json:"reason,omitempty"`
+	Recreated        *CreateResult `json:"recreated,omitempty"`
+	RecreateError    string        `json:"recreate_error,omitempty"`
+}
+
+// Relocate locates the commented code of every outdated thread in the file at
+// the pull request head and records the result on the thread.
+func (s *Service) Relocate(pr resolver.Identity, threads []Thread) ([]Thread, error) {
+	branch, err := s.Branch(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A file that cannot be read only affects the threads on it, which are
+	// reported as unmatched with the error as the reason.
+	type fileContent struct {
+		lines []string
+		err   error
+	}
+	contents := make(map[string]fileContent)
+	for i := range threads {
+		thread := &threads[i]
+		if !thread.IsOutdated {
+			continue
+		}
+
+		file, ok := contents[thread.Path]
+		if !ok {
+			content, err := s.FileContent(pr, thread.Path, branch.HeadSHA)
+			var apiErr *ghcli.APIError
+			switch {
+			case errors.As(err, &apiErr) && apiErr.StatusCode == 404:
+			case err != nil:
+				file.err = err
+			default:
+				file.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+			}
+			contents[thread.Path] = file
+		}
+
+		var relocation Relocation
+		if file.err != nil {
+			relocation = Relocation{Match: MatchNone, Reason: file.err.Error()}
+		} else {
+			relocation = locateThread(*thread, file.lines)
+		}
+		thread.Relocation = &relocation
+	}
+	return threads, nil
+}
+
+func locateThread(thread Thread, file []string) Relocation {
+	none := func(reason string) Relocation {
+		return Relocation{Match: MatchNone, Reason: reason}
+	}
+
+	span := 1
+	if thread.OriginalStartLine != nil && thread.OriginalLine != nil && *thread.OriginalLine > *thread.OriginalStartLine {
+		span = *thread.OriginalLine - *thread.OriginalStartLine + 1
+	}
+	hunk := diff.Parse(thread.DiffHunk)
+	if len(hunk) == 0 || len(hunk[len(hunk)-1].Lines) == 0 {
+		return none("thread has no diff hunk to match against")
+	}
+	last := hunk[len(hunk)-1].Lines
+	if last[len(last)-1].Kind == diff.Removed {
+		return none("thread is on removed lines")
+	}
+	if file == nil {
+		return none("file no longer exists at the head commit")
+	}
+
+	anchor := make([]string, 0, span)
+	for _, l := range diff.TailLines(thread.DiffHunk, "RIGHT", span) {
+		anchor = append(anchor, l.Text)
+	}
+	original := 0
+	if thread.OriginalLine != nil {
+		original = *thread.OriginalLine - len(anchor) + 1
+	}
+
+	start, confidence, match := LocateLines(anchor, file, original)
+	if match == MatchNone {
+		return none("commented code not found at the head commit")
+	}
+
+	relocation := Relocation{
+		CurrentLine: start + len(anchor) - 1,
+		Confidence:  confidence,
+		Match:       match,
+	}
+	if len(anchor) > 1 {
+		relocation.CurrentStartLine = &start
+	}
+	return relocation
+}
+
+// LocateLines finds the 1-based line where anchor starts in file. Exact
+// matches (ignoring surrounding whitespace) are preferred; otherwise the window
+// with the highest average line similarity is used. Ties go to the candidate
+// closest to near. Confidence is 1 for a unique exact match and lower for
+// ambiguous or fuzzy ones.
+func LocateLines(anchor, file []string, near int) (int, float64, string) {
+	if len(anchor) == 0 || len(anchor) > len(file) {
+		return 0, 0, MatchNone
+	}
+
+	normalized := make([]string, len(file))
+	for i, line := range file {
+		normalized[i] = normalizeLine(line)
+	}
+	want := make([]string, len(anchor))
+	for i, line := range anchor {
+		want[i] = normalizeLine(line)
+	}
+
+	var exact []int
+	for i := 0; i+len(want) <= len(normalized); i++ {
+		matched := true
+		for j := range want {
+			if normalized[i+j] != want[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			exact = append(exact, i+1)
+		}
+	}
+	if len(exact) > 0 {
+		confidence := math.Max(0.5, 1-0.1*float64(len(exact)-1))
+		return closestTo(exact, near), round2(confidence), MatchExact
+	}
+
+	best, bestScore := 0, 0.0
+	for i := 0; i+len(want) <= len(normalized); i++ {
+		score := 0.0
+		for j := range want {
+			score += similarity(normalized[i+j], want[j])
+		}
+		score /= float64(len(want))
+		if score > bestScore || (score == bestScore && best > 0 && absInt(i+1-near) < absInt(best-near)) {
+			best, bestScore = i+1, score
+		}
+	}
+	if bestScore < minFuzzyScore {
+		return 0, 0, MatchNone
+	}
+	// A fuzzy match never claims the certainty of an exact one.
+	return best, round2(bestScore * 0.9), MatchFuzzy
+}
+
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// similarity is the Sørensen–Dice coefficient of the strings' character bigrams.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	bigrams := make(map[string]int)
+	for i := 0; i+1 < len(a); i++ {
+		bigrams[a[i:i+2]]++
+	}
+	shared := 0
+	for i := 0; i+1 < len(b); i++ {
+		if bigrams[b[i:i+2]] > 0 {
+			bigrams[b[i:i+2]]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)-1+len(b)-1)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Recreate opens a fresh thread at the relocated position of an outdated thread,
+// quoting its conversation, then points the old thread at the new one and
+// resolves it. The new position must be part of the pull request diff.
+func (s *Service) Recreate(pr resolver.Identity, thread Thread, files []diff.File) (CreateResult, error) {
+	relocation := thread.Relocation
+	if relocation == nil || relocation.CurrentLine == 0 {
+		return CreateResult{}, errors.New("thread has not been relocated")
+	}
+
+	file, ok := findFile(files, thread.Path)
+	if !ok {
+		return CreateResult{}, errors.New("path is not changed in the pull request")
+	}
+	start := relocation.CurrentLine
+	if relocation.CurrentStartLine != nil {
+		start = *relocation.CurrentStartLine
+	}
+	for line := start; line <= relocation.CurrentLine; line++ {
+		if !diff.Contains(file.Hunks, "RIGHT", line) {
+			return CreateResult{}, fmt.Errorf("line %d is outside the pull request diff", line)
+		}
+	}
+
+	input := CreateInput{
+		Path:      thread.Path,
+		Line:      relocation.CurrentLine,
+		Side:      "RIGHT",
+		StartLine: relocation.CurrentStartLine,
+		Body:      recreateBody(thread),
+	}
+	if relocation.CurrentStartLine != nil {
+		side := "RIGHT"
+		input.StartSide = &side
+	}
+	created, err := s.Create(pr, input)
+	if err != nil {
+		return CreateResult{}, err
+	}
+
+	if _, err := s.Reply(thread.ID, fmt.Sprintf("Moved to %s.", created.URL)); err != nil {
+		return created, fmt.Errorf("link outdated thread: %w", err)
+	}
+	if _, err := s.Resolve(thread.ID); err != nil {
+		return created, fmt.Errorf("resolve outdated thread: %w", err)
+	}
+	return created, nil
+}
+
+func recreateBody(thread Thread) string {
+	var b strings.Builder
+	for i, c := range thread.Comments {
+		verb := "replied"
+		if i == 0 {
+			verb = "commented"
+		}
+		fmt.Fprintf(&b, "> **@%s** [%s](%s) on %s:\n>\n%s\n\n", c.Author.Login, verb, c.URL, c.CreatedAt, quoteMarkdown(c.Body))
+	}
+	url := ""
+	if len(thread.Comments) > 0 {
+		url = thread.Comments[0].URL
+	}
+	fmt.Fprintf(&b, "_Moved from an outdated thread (%s)._", url)
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package comments
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+func TestRelocateRecordsUnreadableFilesPerThread(t *testing.T) {
+	backend := ghfake.New("github.com", "octocat")
+	backend.AddPullRequest(&ghfake.PullRequest{
+		Owner:   "acme",
+		Repo:    "widgets",
+		Number:  7,
+		HeadSHA: "abc1234def",
+		Files: []ghfake.File{
+			{Filename: "main.go", Patch: testPatch, Content: "package main\n\nimport \"fmt\"\n\nfunc main() {}\n"},
+		},
+	})
+	service := NewService(unreadableAPI{backend})
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 7}
+
+	line := 2
+	outdated := func(path string) Thread {
+		return Thread{ID: "PRRT_" + path, Path: path, OriginalLine: &line, IsOutdated: true, DiffHunk: "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\""}
+	}
+	threads, err := service.Relocate(pr, []Thread{outdated("big.go"), outdated("main.go")})
+	if err != nil {
+		t.Fatalf("relocate: %v", err)
+	}
+
+	big := threads[0].Relocation
+	if big == nil || big.Match != MatchNone || !strings.Contains(big.Reason, "unsupported encoding") {
+		t.Fatalf("big.go relocation = %+v, want match none with the read error", big)
+	}
+	moved := threads[1].Relocation
+	if moved == nil || moved.Match != MatchExact || moved.CurrentLine != 3 {
+		t.Fatalf("main.go relocation = %+v, want an exact match on line 3", moved)
+	}
+}
+
+// unreadableAPI serves big.go like GitHub serves files over 1 MB: as a file
+// entry without base64 content.
+type unreadableAPI struct {
+	*ghfake.Backend
+}
+
+func (a unreadableAPI) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	if strings.HasSuffix(path, "/contents/big.go") {
+		return json.Unmarshal([]byte(`{"type": "file", "encoding": "none", "content": ""}`), result)
+	}
+	return a.Backend.REST(method, path, params, body, result)
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"return 1", "return 1", 1},
+		{"x", "x", 1},
+		{"a", "b", 0},
+		{"", "ab", 0},
+		{"abc", "xyz", 0},
+		{"abc", "abd", 0.5},
+		{"night", "nacht", 0.25},
+		// Repeated bigrams are only shared as often as both strings have them.
+		{"aaaa", "aa", 0.5},
+		{"return 1", "return 2", 12.0 / 14},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := similarity(tt.b, tt.a); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want it symmetric", tt.b, tt.a, got)
+		}
+	}
+}
+
+func TestLocateLines(t *testing.T) {
+	file := []string{
+		"package main",
+		"",
+		"func a() {",
+		"\treturn 1",
+		"}",
+		"",
+		"func b() {",
+		"\treturn 1",
+		"}",
+	}
+	repeated := []string{"x", "x", "x", "x", "x", "x", "x", "x"}
+
+	tests := []struct {
+		name       string
+		anchor     []string
+		file       []string
+		near       int
+		line       int
+		confidence float64
+		match      string
+	}{
+		{name: "unique exact", anchor: []string{"func b() {"}, file: file, line: 7, confidence: 1, match: MatchExact},
+		{name: "whitespace ignored", anchor: []string{"  func   b() {  "}, file: file, line: 7, confidence: 1, match: MatchExact},
+		{name: "multi-line exact", anchor: []string{"func a() {", "return 1"}, file: file, line: 3, confidence: 1, match: MatchExact},
+		{name: "duplicate near the second", anchor: []string{"return 1"}, file: file, near: 8, line: 8, confidence: 0.9, match: MatchExact},
+		{name: "duplicate near the first", anchor: []string{"return 1"}, file: file, near: 3, line: 4, confidence: 0.9, match: MatchExact},
+		{name: "confidence floor", anchor: []string{"x"}, file: repeated, near: 5, line: 5, confidence: 0.5, match: MatchExact},
+		{name: "fuzzy", anchor: []string{"func b() { // entry"}, file: file, line: 7, confidence: 0.6, match: MatchFuzzy},
+		{name: "fuzzy tie near the second", anchor: []string{"return 2"}, file: file, near: 7, line: 8, confidence: 0.77, match: MatchFuzzy},
+		{name: "fuzzy tie keeps the first", anchor: []string{"return 2"}, file: file, line: 4, confidence: 0.77, match: MatchFuzzy},
+		{name: "below the fuzzy threshold", anchor: []string{"completely unrelated text"}, file: file, match: MatchNone},
+		{name: "anchor longer than file", anchor: file, file: file[:3], match: MatchNone},
+		{name: "empty anchor", file: file, match: MatchNone},
+	}
+	for _, tt := range tests {
+		line, confidence, match := LocateLines(tt.anchor, tt.file, tt.near)
+		if line != tt.line || confidence != tt.confidence || match != tt.match {
+			t.Errorf("%s: LocateLines() = %d, %v, %s, want %d, %v, %s", tt.name, line, confidence, match, tt.line, tt.confidence, tt.match)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package comments
+
+import (
+	"errors"
+	"strings"
+)
+
+const replyMutation = `mutation AddPullRequestReviewThreadReply($input: AddPullRequestReviewThreadReplyInput!) {
+  addPullRequestReviewThreadReply(input: $input) {
+    comment {
+      id
+      body
+      createdAt
+      url
+      authorAssociation
+      author { login __typename }
+    }
+  }
+}`
+
+const resolveThreadMutation = `mutation ResolveReviewThread($input: ResolveReviewThreadInput!) {
+  resolveReviewThread(input: $input) {
+    thread {
+      id
+      isResolved
+    }
+  }
+}`
+
+const unresolveThreadMutation = `mutation UnresolveReviewThread($input: UnresolveReviewThreadInput!) {
+  unresolveReviewThread(input: $input) {
+    thread {
+      id
+      isResolved
+    }
+  }
+}`
+
+// ResolveResult reports the resolution state of a thread after a resolve/unresolve operation.
+type ResolveResult struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"is_resolved"`
+}
+
+// Reply adds a comment to an existing review thread.
+func (s *Service) Reply(threadID, body string) (Comment, error) {
+	id := strings.TrimSpace(threadID)
+	if id == "" {
+		return Comment{}, errors.New("thread id is required")
+	}
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return Comment{}, errors.New("body is required")
+	}
+
+	var response struct {
+		AddPullRequestReviewThreadReply struct {
+			Comment *struct {
+				ID                string     `json:"id"`
+				Body              string     `json:"body"`
+				CreatedAt         string     `json:"createdAt"`
+				URL               string     `json:"url"`
+				AuthorAssociation string     `json:"authorAssociation"`
+				Author            *actorNode `json:"author"`
+			} `json:"comment"`
+		} `json:"addPullRequestReviewThreadReply"`
+	}
+
+	input := map[string]interface{}{
+		"pullRequestReviewThreadId": id,
+		"body":                      body,
+	}
+	if err := s.API.GraphQL(replyMutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return Comment{}, err
+	}
+
+	comment := response.AddPullRequestReviewThreadReply.Comment
+	if comment == nil {
+		return Comment{}, errors.New("reply response missing comment")
+	}
+
+	return Comment{
+		ID:        comment.ID,
+		Body:      comment.Body,
+		Author:    newAuthor(comment.Author, comment.AuthorAssociation),
+		CreatedAt: comment.CreatedAt,
+		URL:       comment.URL,
+	}, nil
+}
+
+// Resolve marks a review thread as resolved.
+func (s *Service) Resolve(threadID string) (ResolveResult, error) {
+	return s.setResolved(threadID, resolveThreadMutation, "resolveReviewThread")
+}
+
+// Unresolve reopens a resolved review thread.
+func (s *Service) Unresolve(threadID string) (ResolveResult, error) {
+	return s.setResolved(threadID, unresolveThreadMutation, "unresolveReviewThread")
+}
+
+func (s *Service) setResolved(threadID, mutation, field string) (ResolveResult, error) {
+	id := strings.TrimSpace(threadID)
+	if id == "" {
+		return ResolveResult{}, errors.New("thread id is required")
+	}
+
+	var response map[string]struct {
+		Thread *struct {
+			ID         string `json:"id"`
+			IsResolved bool   `json:"isResolved"`
+		} `json:"thread"`
+	}
+
+	input := map[string]interface{}{"threadId": id}
+	if err := s.API.GraphQL(mutation, map[string]interface{}{"input": input}, &response); err != nil {
+		return ResolveResult{}, err
+	}
+
+	thread := response[field].Thread
+	if thread == nil {
+		return ResolveResult{}, errors.New(field + " response missing thread")
+	}
+	return ResolveResult{ID: thread.ID, IsResolved: thread.IsResolved}, nil
+}
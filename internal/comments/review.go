@@ -0,0 +1,89 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const addReviewMutation = `mutation AddPullRequestReview($input: AddPullRequestReviewInput!) {
+  addPullRequestReview(input: $input) {
+    pullRequestReview {
+      id
+      state
+      url
+      comments(first: 1) {
+        totalCount
+      }
+    }
+  }
+}`
+
+// ReviewResult reports a review submitted with a batch of inline threads.
+type ReviewResult struct {
+	ID       string `json:"id"`
+	State    string `json:"state"`
+	URL      string `json:"url"`
+	Comments int    `json:"comments"`
+}
+
+// CreateReview submits a single COMMENT review containing one new thread per input.
+func (s *Service) CreateReview(pr resolver.Identity, body string, inputs []CreateInput) (ReviewResult, error) {
+	if len(inputs) == 0 {
+		return ReviewResult{}, errors.New("at least one comment is required")
+	}
+
+	threads := make([]map[string]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		thread, _, err := threadInput(input)
+		if err != nil {
+			return ReviewResult{}, fmt.Errorf("comment %d: %w", i+1, err)
+		}
+		threads = append(threads, thread)
+	}
+
+	prID, err := s.PullRequestID(pr)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+
+	mutationInput := map[string]interface{}{
+		"pullRequestId": prID,
+		"event":         "COMMENT",
+		"threads":       threads,
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		mutationInput["body"] = body
+	}
+
+	var response struct {
+		AddPullRequestReview struct {
+			PullRequestReview *struct {
+				ID       string `json:"id"`
+				State    string `json:"state"`
+				URL      string `json:"url"`
+				Comments struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"comments"`
+			} `json:"pullRequestReview"`
+		} `json:"addPullRequestReview"`
+	}
+
+	if err := s.API.GraphQL(addReviewMutation, map[string]interface{}{"input": mutationInput}, &response); err != nil {
+		return ReviewResult{}, err
+	}
+
+	review := response.AddPullRequestReview.PullRequestReview
+	if review == nil {
+		return ReviewResult{}, errors.New("create review response missing review")
+	}
+
+	return ReviewResult{
+		ID:       review.ID,
+		State:    review.State,
+		URL:      review.URL,
+		Comments: review.Comments.TotalCount,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package comments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/sarif"
+)
+
+const sarifMarkerPrefix = "<!-- gh-pr-comments:sarif "
+
+// SkippedFinding reports a SARIF finding that was not posted.
+type SkippedFinding struct {
+	RuleID string `json:"rule_id"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// SARIFPlan holds the comments to post for a SARIF log and the findings dropped.
+type SARIFPlan struct {
+	Inputs  []CreateInput
+	Skipped []SkippedFinding
+}
+
+// PlanSARIF maps findings to inline comments on the head side of the diff. Findings
+// outside the diff are dropped, and findings already posted (recognized by a hidden
+// fingerprint marker in existing thread comments) are deduplicated.
+func PlanSARIF(findings []sarif.Finding, files []diff.File, existing []Thread) SARIFPlan {
+	posted := make(map[string]bool)
+	for _, thread := range existing {
+		for _, c := range thread.Comments {
+			if marker := hiddenMarker(c.Body, sarifMarkerPrefix); marker != "" {
+				posted[marker] = true
+			}
+		}
+	}
+
+	plan := SARIFPlan{
+		Inputs:  make([]CreateInput, 0, len(findings)),
+		Skipped: make([]SkippedFinding, 0),
+	}
+	for _, f := range findings {
+		skip := func(reason string) {
+			plan.Skipped = append(plan.Skipped, SkippedFinding{RuleID: f.RuleID, Path: f.Path, Line: f.EndLine, Reason: reason})
+		}
+
+		file, ok := findFile(files, f.Path)
+		if !ok {
+			skip("path is not changed in the pull request")
+			continue
+		}
+		if !diff.Contains(file.Hunks, "RIGHT", f.EndLine) {
+			skip("line is outside the pull request diff")
+			continue
+		}
+
+		fingerprint := sarifFingerprint(f)
+		if posted[fingerprint] {
+			skip("already posted")
+			continue
+		}
+		posted[fingerprint] = true
+
+		input := CreateInput{
+			Path: f.Path,
+			Line: f.EndLine,
+			Side: "RIGHT",
+			Body: sarifBody(f, fingerprint),
+		}
+		if f.StartLine < f.EndLine && sameHunk(file.Hunks, f.StartLine, f.EndLine) {
+			start := f.StartLine
+			startSide := "RIGHT"
+			input.StartLine = &start
+			input.StartSide = &startSide
+		}
+		plan.Inputs = append(plan.Inputs, input)
+	}
+
+	return plan
+}
+
+func sameHunk(hunks []diff.Hunk, start, end int) bool {
+	for _, h := range hunks {
+		if diff.Contains([]diff.Hunk{h}, "RIGHT", start) && diff.Contains([]diff.Hunk{h}, "RIGHT", end) {
+			return true
+		}
+	}
+	return false
+}
+
+func sarifFingerprint(f sarif.Finding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.Tool, f.RuleID, f.Path, fmt.Sprint(f.StartLine), f.Message}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// hiddenMarker returns the value of the HTML comment marker starting with prefix
+// in body, or "" when there is none.
+func hiddenMarker(body, prefix string) string {
+	i := strings.Index(body, prefix)
+	if i < 0 {
+		return ""
+	}
+	rest := body[i+len(prefix):]
+	end := strings.Index(rest, " -->")
+	if end < 0 {
+		return ""
+	}
+	return rest[:end]
+}
+
+func sarifBody(f sarif.Finding, fingerprint string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", strings.ToUpper(f.Level))
+	if f.RuleID != "" {
+		fmt.Fprintf(&b, " `%s`", f.RuleID)
+	}
+	if f.Tool != "" {
+		fmt.Fprintf(&b, " (%s)", f.Tool)
+	}
+	fmt.Fprintf(&b, ": %s\n\n%s%s -->", strings.TrimSpace(f.Message), sarifMarkerPrefix, fingerprint)
+	return b.String()
+}
+
+// sarifThreadRule is the rule ID attached to review threads exported as SARIF.
+const sarifThreadRule = "review-thread"
+
+// ThreadsSARIF converts unresolved threads into a SARIF log so review feedback can
+// be tracked in code-scanning tooling. Outdated threads are kept at their original
+// lines with level "note".
+func ThreadsSARIF(threads []Thread) sarif.Log {
+	diagnostics := Diagnostics(threads, true)
+	results := make([]sarif.Result, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		level := "warning"
+		if d.Outdated {
+			level = "note"
+		}
+		results = append(results, sarif.Result{
+			RuleID:  sarifThreadRule,
+			Level:   level,
+			Message: sarif.Message{Text: fmt.Sprintf("%s (%s)", d.Message, d.URL)},
+			Locations: []sarif.Location{{
+				PhysicalLocation: sarif.PhysicalLocation{
+					ArtifactLocation: sarif.ArtifactLocation{URI: d.Path},
+					Region:           &sarif.Region{StartLine: d.StartLine, EndLine: d.EndLine},
+				},
+			}},
+			PartialFingerprints: map[string]string{"reviewThreadId": d.ThreadID},
+			HostedViewerURI:     d.URL,
+			Properties: map[string]interface{}{
+				"thread_id":   d.ThreadID,
+				"url":         d.URL,
+				"author":      d.Author,
+				"is_outdated": d.Outdated,
+			},
+		})
+	}
+
+	return sarif.Log{
+		Version: sarif.Version,
+		Schema:  sarif.Schema,
+		Runs: []sarif.Run{{
+			Tool: sarif.Tool{Driver: sarif.Driver{
+				Name:           "gh-pr-comments",
+				InformationURI: "https://github.com/agynio/gh-pr-review",
+				Rules: []sarif.Rule{{
+					ID:               sarifThreadRule,
+					ShortDescription: &sarif.Message{Text: "Unresolved pull request review thread"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+}
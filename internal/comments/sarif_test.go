@@ -0,0 +1,194 @@
+package comments
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/sarif"
+)
+
+func TestPlanSARIF(t *testing.T) {
+	files := []diff.File{{
+		Path: "main.go",
+		Hunks: diff.Parse("@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"\n \n func main() {}\n" +
+			"@@ -20,2 +21,3 @@\n func b() {}\n+func c() {}\n "),
+	}}
+	finding := func(rule string, start, end int) sarif.Finding {
+		return sarif.Finding{Tool: "vet", RuleID: rule, Level: "warning", Message: rule + " found", Path: "main.go", StartLine: start, EndLine: end}
+	}
+	posted := finding("posted-before", 21, 21)
+	existing := []Thread{{Comments: []Comment{{Body: sarifBody(posted, sarifFingerprint(posted))}}}}
+	outside := finding("outside", 10, 10)
+	other := finding("other-file", 1, 1)
+	other.Path = "other.go"
+
+	plan := PlanSARIF([]sarif.Finding{
+		finding("single", 2, 2),
+		finding("range", 2, 4),
+		finding("across-hunks", 3, 22),
+		outside,
+		other,
+		finding("single", 2, 2),
+		posted,
+	}, files, existing)
+
+	type placement struct{ line, start int }
+	want := []placement{{line: 2}, {line: 4, start: 2}, {line: 22}}
+	if len(plan.Inputs) != len(want) {
+		t.Fatalf("planned %d comments, want %d: %+v", len(plan.Inputs), len(want), plan.Inputs)
+	}
+	for i, input := range plan.Inputs {
+		got := placement{line: input.Line}
+		if input.StartLine != nil {
+			got.start = *input.StartLine
+			if input.StartSide == nil || *input.StartSide != "RIGHT" {
+				t.Errorf("input %d: start side = %v, want RIGHT", i, input.StartSide)
+			}
+		}
+		if got != want[i] || input.Side != "RIGHT" || input.Path != "main.go" {
+			t.Errorf("input %d = %s %s:%d (start %d), want line %d (start %d)", i, input.Side, input.Path, got.line, got.start, want[i].line, want[i].start)
+		}
+		if hiddenMarker(input.Body, sarifMarkerPrefix) == "" {
+			t.Errorf("input %d body has no fingerprint marker: %q", i, input.Body)
+		}
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range plan.Skipped {
+		reasons[s.RuleID] = s.Reason
+	}
+	wantReasons := map[string]string{
+		"outside":       "line is outside the pull request diff",
+		"other-file":    "path is not changed in the pull request",
+		"single":        "already posted",
+		"posted-before": "already posted",
+	}
+	if len(plan.Skipped) != len(wantReasons) {
+		t.Fatalf("skipped = %+v, want %d entries", plan.Skipped, len(wantReasons))
+	}
+	for rule, reason := range wantReasons {
+		if reasons[rule] != reason {
+			t.Errorf("%s skipped with %q, want %q", rule, reasons[rule], reason)
+		}
+	}
+}
+
+func TestSARIFFingerprintIgnoresEndLine(t *testing.T) {
+	a := sarif.Finding{Tool: "vet", RuleID: "r", Path: "main.go", StartLine: 2, EndLine: 2, Message: "m"}
+	b := a
+	b.EndLine = 4
+	if sarifFingerprint(a) != sarifFingerprint(b) {
+		t.Fatal("fingerprint changed with the end line")
+	}
+	b.Message = "other"
+	if sarifFingerprint(a) == sarifFingerprint(b) {
+		t.Fatal("fingerprint ignores the message")
+	}
+}
+
+func TestThreadsSARIF(t *testing.T) {
+	threads := []Thread{
+		{
+			ID:        "PRRT_open",
+			Path:      "main.go",
+			Line:      intPtr(4),
+			StartLine: intPtr(2),
+			Comments:  []Comment{{Body: "Use log", Author: Author{Login: "bob"}, URL: "https://github.com/acme/widgets/pull/7#discussion_r1"}},
+		},
+		{
+			ID:           "PRRT_outdated",
+			Path:         "util.go",
+			OriginalLine: intPtr(9),
+			IsOutdated:   true,
+			Comments:     []Comment{{Body: "Stale", Author: Author{Login: "carol"}, URL: "https://github.com/acme/widgets/pull/7#discussion_r2"}},
+		},
+		{
+			ID:         "PRRT_resolved",
+			Path:       "main.go",
+			Line:       intPtr(1),
+			IsResolved: true,
+			Comments:   []Comment{{Body: "Done", Author: Author{Login: "bob"}}},
+		},
+	}
+
+	data, err := json.Marshal(ThreadsSARIF(threads))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Schema  string `json:"$schema"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string                `json:"ruleId"`
+				Level     string                `json:"level"`
+				Message   struct{ Text string } `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+							EndLine   int `json:"endLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				PartialFingerprints map[string]string `json:"partialFingerprints"`
+				HostedViewerURI     string            `json:"hostedViewerUri"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if log.Version != "2.1.0" || !strings.Contains(log.Schema, "sarif-2.1.0") {
+		t.Fatalf("version = %q, $schema = %q, want SARIF 2.1.0", log.Version, log.Schema)
+	}
+	if len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "gh-pr-comments" {
+		t.Fatalf("runs = %+v, want one gh-pr-comments run", log.Runs)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != sarifThreadRule {
+		t.Fatalf("rules = %+v, want %s", run.Tool.Driver.Rules, sarifThreadRule)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("results = %+v, want the two unresolved threads", run.Results)
+	}
+
+	open, outdated := run.Results[0], run.Results[1]
+	if open.RuleID != sarifThreadRule || open.Level != "warning" {
+		t.Errorf("open result = %s/%s, want %s/warning", open.RuleID, open.Level, sarifThreadRule)
+	}
+	location := open.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "main.go" || location.Region.StartLine != 2 || location.Region.EndLine != 4 {
+		t.Errorf("open location = %+v, want main.go lines 2-4", location)
+	}
+	if open.PartialFingerprints["reviewThreadId"] != "PRRT_open" {
+		t.Errorf("partialFingerprints = %v, want the thread id", open.PartialFingerprints)
+	}
+	if open.HostedViewerURI != threads[0].Comments[0].URL {
+		t.Errorf("hostedViewerUri = %q, want the thread url", open.HostedViewerURI)
+	}
+	if !strings.Contains(open.Message.Text, "@bob: Use log") {
+		t.Errorf("message = %q", open.Message.Text)
+	}
+
+	if outdated.Level != "note" || outdated.PartialFingerprints["reviewThreadId"] != "PRRT_outdated" {
+		t.Errorf("outdated result = %+v, want a note", outdated)
+	}
+	if region := outdated.Locations[0].PhysicalLocation.Region; region.StartLine != 9 || region.EndLine != 9 {
+		t.Errorf("outdated region = %+v, want the original line 9", region)
+	}
+}
@@ -0,0 +1,207 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const searchPullRequestsQuery = `query SearchPullRequests($query: String!, $first: Int!, $after: String) {
+  search(query: $query, type: ISSUE, first: $first, after: $after) {
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      ... on PullRequest {
+        number
+        url
+        title
+        state
+        authorAssociation
+        author { login __typename }
+      }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+const (
+	searchPageSize     = 50
+	defaultSearchLimit = 100
+	defaultWorkers     = 4
+)
+
+// SearchCriteria narrows the pull requests enumerated by Search.
+type SearchCriteria struct {
+	State    string
+	Author   string
+	Reviewer string
+	Labels   []string
+	Limit    int
+}
+
+// PullRequestSummary describes one pull request returned by Search.
+type PullRequestSummary struct {
+	Identity resolver.Identity
+	Title    string
+	State    string
+	Author   Author
+}
+
+// PullRequestThreads groups the threads fetched for a single pull request.
+type PullRequestThreads struct {
+	PullRequest PullRequestSummary
+	Threads     []Thread
+	Warnings    []Warning
+	Err         error
+}
+
+// Search enumerates pull requests in a repository through the GraphQL search API.
+func (s *Service) Search(repo resolver.Repository, criteria SearchCriteria) ([]PullRequestSummary, error) {
+	query, err := buildSearchQuery(repo, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := criteria.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	results := make([]PullRequestSummary, 0)
+	var after *string
+	for len(results) < limit {
+		first := searchPageSize
+		if remaining := limit - len(results); remaining < first {
+			first = remaining
+		}
+		variables := map[string]interface{}{
+			"query": query,
+			"first": first,
+		}
+		if after != nil {
+			variables["after"] = *after
+		}
+
+		var response struct {
+			Search struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					Number            int        `json:"number"`
+					URL               string     `json:"url"`
+					Title             string     `json:"title"`
+					State             string     `json:"state"`
+					AuthorAssociation string     `json:"authorAssociation"`
+					Author            *actorNode `json:"author"`
+				} `json:"nodes"`
+			} `json:"search"`
+		}
+
+		if err := s.API.GraphQL(searchPullRequestsQuery, variables, &response); err != nil {
+			return nil, err
+		}
+
+		for _, node := range response.Search.Nodes {
+			if node.Number == 0 {
+				continue
+			}
+			identity, err := resolver.FromURL(node.URL)
+			if err != nil {
+				identity = resolver.Identity{
+					Owner:  repo.Owner,
+					Repo:   repo.Repo,
+					Host:   repo.Host,
+					Number: node.Number,
+					URL:    node.URL,
+				}
+			}
+			summary := PullRequestSummary{
+				Identity: identity,
+				Title:    node.Title,
+				State:    node.State,
+				Author:   newAuthor(node.Author, node.AuthorAssociation),
+			}
+			results = append(results, summary)
+		}
+
+		if !response.Search.PageInfo.HasNextPage {
+			break
+		}
+		cursor := response.Search.PageInfo.EndCursor
+		after = &cursor
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ListMany fetches threads for each pull request using a bounded pool of workers.
+// Results keep the input order; per-PR failures are reported in Err rather than
+// aborting the whole run.
+func (s *Service) ListMany(prs []PullRequestSummary, workers int) []PullRequestThreads {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	results := make([]PullRequestThreads, len(prs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				threads, warnings, err := s.List(prs[i].Identity)
+				results[i] = PullRequestThreads{PullRequest: prs[i], Threads: threads, Warnings: warnings, Err: err}
+			}
+		}()
+	}
+	for i := range prs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func buildSearchQuery(repo resolver.Repository, criteria SearchCriteria) (string, error) {
+	if repo.Owner == "" || repo.Repo == "" {
+		return "", errors.New("repository is required")
+	}
+
+	terms := []string{fmt.Sprintf("repo:%s/%s", repo.Owner, repo.Repo), "is:pr"}
+
+	switch state := strings.ToLower(strings.TrimSpace(criteria.State)); state {
+	case "", "open":
+		terms = append(terms, "is:open")
+	case "closed", "merged":
+		terms = append(terms, "is:"+state)
+	case "all":
+	default:
+		return "", fmt.Errorf("invalid state %q: must be open, closed, merged, or all", criteria.State)
+	}
+
+	if author := strings.TrimSpace(criteria.Author); author != "" {
+		terms = append(terms, "author:"+author)
+	}
+	if reviewer := strings.TrimSpace(criteria.Reviewer); reviewer != "" {
+		terms = append(terms, "reviewed-by:"+reviewer)
+	}
+	for _, label := range criteria.Labels {
+		if label = strings.TrimSpace(label); label != "" {
+			terms = append(terms, fmt.Sprintf("label:%q", label))
+		}
+	}
+
+	return strings.Join(terms, " "), nil
+}
@@ -0,0 +1,158 @@
+package comments
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+// countingAPI records how many GraphQL requests are in flight at once and how
+// many were made per operation.
+type countingAPI struct {
+	ghcli.API
+	delay time.Duration
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       map[string]int
+}
+
+func (c *countingAPI) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[ghcli.OperationName(query)]++
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+	err := c.API.GraphQL(query, variables, result)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return err
+}
+
+func newSearchBackend(count int) *ghfake.Backend {
+	backend := ghfake.New("github.com", "octocat")
+	for n := 1; n <= count; n++ {
+		pull := &ghfake.PullRequest{Owner: "acme", Repo: "widgets", Number: n, Title: fmt.Sprintf("PR %d", n), Author: "alice", HeadSHA: "abc1234def"}
+		for i := 0; i < n%3; i++ {
+			pull.Threads = append(pull.Threads, &ghfake.Thread{
+				Path:     "main.go",
+				Line:     intPtr(1),
+				Comments: []*ghfake.Comment{{Author: "bob", Body: "nit"}},
+			})
+		}
+		backend.AddPullRequest(pull)
+	}
+	return backend
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestSearchPagesUpToLimit(t *testing.T) {
+	repo := resolver.Repository{Owner: "acme", Repo: "widgets", Host: "github.com"}
+	tests := []struct {
+		limit     int
+		wantCount int
+		wantPages int
+	}{
+		{limit: 70, wantCount: 70, wantPages: 2},
+		{limit: 0, wantCount: defaultSearchLimit, wantPages: 2},
+		{limit: 500, wantCount: 120, wantPages: 3},
+	}
+	for _, tt := range tests {
+		api := &countingAPI{API: newSearchBackend(120)}
+		prs, err := NewService(api).Search(repo, SearchCriteria{Limit: tt.limit})
+		if err != nil {
+			t.Fatalf("limit %d: %v", tt.limit, err)
+		}
+		if len(prs) != tt.wantCount {
+			t.Errorf("limit %d: got %d pull requests, want %d", tt.limit, len(prs), tt.wantCount)
+		}
+		for i, pr := range prs {
+			if pr.Identity.Number != i+1 {
+				t.Fatalf("limit %d: result %d is #%d, want #%d", tt.limit, i, pr.Identity.Number, i+1)
+			}
+		}
+		if pages := api.calls["SearchPullRequests"]; pages != tt.wantPages {
+			t.Errorf("limit %d: made %d search requests, want %d", tt.limit, pages, tt.wantPages)
+		}
+	}
+}
+
+func TestSearchRejectsUnknownState(t *testing.T) {
+	repo := resolver.Repository{Owner: "acme", Repo: "widgets"}
+	if _, err := NewService(newSearchBackend(1)).Search(repo, SearchCriteria{State: "draft"}); err == nil {
+		t.Fatal("expected an error for an unknown state")
+	}
+}
+
+func TestListManyKeepsOrderWithBoundedWorkers(t *testing.T) {
+	api := &countingAPI{API: newSearchBackend(12), delay: 2 * time.Millisecond}
+	service := NewService(api)
+
+	prs := make([]PullRequestSummary, 0, 13)
+	for n := 1; n <= 12; n++ {
+		if n == 6 {
+			// A pull request that does not exist fails in the middle of the batch.
+			prs = append(prs, PullRequestSummary{Identity: resolver.Identity{Owner: "acme", Repo: "widgets", Number: 404}})
+		}
+		prs = append(prs, PullRequestSummary{Identity: resolver.Identity{Owner: "acme", Repo: "widgets", Number: n}})
+	}
+
+	results := service.ListMany(prs, 3)
+	if len(results) != len(prs) {
+		t.Fatalf("got %d results, want %d", len(results), len(prs))
+	}
+	for i, result := range results {
+		number := prs[i].Identity.Number
+		if result.PullRequest.Identity.Number != number {
+			t.Fatalf("result %d is #%d, want #%d", i, result.PullRequest.Identity.Number, number)
+		}
+		if number == 404 {
+			if result.Err == nil || !strings.Contains(result.Err.Error(), "not found") {
+				t.Fatalf("#404 err = %v, want not found", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Fatalf("#%d: %v", number, result.Err)
+		}
+		if len(result.Threads) != number%3 {
+			t.Fatalf("#%d has %d threads, want %d", number, len(result.Threads), number%3)
+		}
+	}
+	if api.maxInFlight > 3 {
+		t.Fatalf("%d requests in flight, want at most 3 workers", api.maxInFlight)
+	}
+}
+
+func TestListManyDefaultsWorkers(t *testing.T) {
+	api := &countingAPI{API: newSearchBackend(8), delay: time.Millisecond}
+	prs := make([]PullRequestSummary, 0, 8)
+	for n := 1; n <= 8; n++ {
+		prs = append(prs, PullRequestSummary{Identity: resolver.Identity{Owner: "acme", Repo: "widgets", Number: n}})
+	}
+	if results := NewService(api).ListMany(prs, 0); len(results) != 8 {
+		t.Fatalf("got %d results, want 8", len(results))
+	}
+	if api.maxInFlight > defaultWorkers {
+		t.Fatalf("%d requests in flight, want at most %d", api.maxInFlight, defaultWorkers)
+	}
+}
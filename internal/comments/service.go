@@ -18,6 +18,8 @@ const listThreadsQuery = `query PullRequestInlineComments($owner: String!, $name
           path
           line
           startLine
+          originalLine
+          originalStartLine
           isResolved
           isOutdated
           comments(first: $firstComments) {
@@ -26,13 +28,18 @@ const listThreadsQuery = `query PullRequestInlineComments($owner: String!, $name
               body
               createdAt
               url
-              author { login }
+              isMinimized
+              minimizedReason
+              diffHunk
+              authorAssociation
+              author { login __typename }
             }
           }
         }
       }
     }
   }
+  rateLimit { cost remaining resetAt }
 }`
 
 const pullRequestNodeQuery = `query PullRequestNode($owner: String!, $name: String!, $number: Int!) {
@@ -41,6 +48,7 @@ const pullRequestNodeQuery = `query PullRequestNode($owner: String!, $name: Stri
       id
     }
   }
+  rateLimit { cost remaining resetAt }
 }`
 
 const createThreadMutation = `mutation AddPullRequestReviewThread($input: AddPullRequestReviewThreadInput!) {
@@ -58,7 +66,8 @@ const createThreadMutation = `mutation AddPullRequestReviewThread($input: AddPul
           body
           createdAt
           url
-          author { login }
+          authorAssociation
+          author { login __typename }
         }
       }
     }
@@ -82,22 +91,28 @@ func NewService(api ghcli.API) *Service {
 
 // Comment represents one inline PR review comment.
 type Comment struct {
-	ID        string `json:"id"`
-	Body      string `json:"body"`
-	Author    string `json:"author"`
-	CreatedAt string `json:"created_at"`
-	URL       string `json:"url"`
+	ID              string `json:"id"`
+	Body            string `json:"body"`
+	Author          Author `json:"author"`
+	CreatedAt       string `json:"created_at"`
+	URL             string `json:"url"`
+	IsMinimized     bool   `json:"is_minimized"`
+	MinimizedReason string `json:"minimized_reason,omitempty"`
 }
 
 // Thread represents an inline review thread on a PR diff.
 type Thread struct {
-	ID         string    `json:"id"`
-	Path       string    `json:"path"`
-	Line       *int      `json:"line,omitempty"`
-	StartLine  *int      `json:"start_line,omitempty"`
-	IsResolved bool      `json:"is_resolved"`
-	IsOutdated bool      `json:"is_outdated"`
-	Comments   []Comment `json:"comments"`
+	ID                string      `json:"id"`
+	Path              string      `json:"path"`
+	Line              *int        `json:"line,omitempty"`
+	StartLine         *int        `json:"start_line,omitempty"`
+	OriginalLine      *int        `json:"original_line,omitempty"`
+	OriginalStartLine *int        `json:"original_start_line,omitempty"`
+	IsResolved        bool        `json:"is_resolved"`
+	IsOutdated        bool        `json:"is_outdated"`
+	DiffHunk          string      `json:"diff_hunk,omitempty"`
+	Relocation        *Relocation `json:"relocation,omitempty"`
+	Comments          []Comment   `json:"comments"`
 }
 
 // CreateInput holds parameters for creating an inline comment thread.
@@ -112,22 +127,24 @@ type CreateInput struct {
 
 // CreateResult returns normalized details for a newly-created inline comment thread.
 type CreateResult struct {
-	ThreadID    string `json:"thread_id"`
-	CommentID   string `json:"comment_id"`
-	Path        string `json:"path"`
-	Line        *int   `json:"line,omitempty"`
-	StartLine   *int   `json:"start_line,omitempty"`
-	Author      string `json:"author"`
-	Body        string `json:"body"`
-	CreatedAt   string `json:"created_at"`
-	URL         string `json:"url"`
-	IsResolved  bool   `json:"is_resolved"`
-	IsOutdated  bool   `json:"is_outdated"`
-	RequestedOn string `json:"requested_side"`
+	ThreadID      string `json:"thread_id"`
+	CommentID     string `json:"comment_id"`
+	Path          string `json:"path"`
+	Line          *int   `json:"line,omitempty"`
+	StartLine     *int   `json:"start_line,omitempty"`
+	Author        Author `json:"author"`
+	Body          string `json:"body"`
+	CreatedAt     string `json:"created_at"`
+	URL           string `json:"url"`
+	IsResolved    bool   `json:"is_resolved"`
+	IsOutdated    bool   `json:"is_outdated"`
+	RequestedSide string `json:"requested_side"`
 }
 
-// List fetches inline review threads/comments for a pull request.
-func (s *Service) List(pr resolver.Identity) ([]Thread, error) {
+// List fetches inline review threads/comments for a pull request. When GitHub
+// returns only part of the threads, for example because some fields are
+// forbidden, the rest is returned together with a warning per error.
+func (s *Service) List(pr resolver.Identity) ([]Thread, []Warning, error) {
 	variables := map[string]interface{}{
 		"owner":         pr.Owner,
 		"name":          pr.Repo,
@@ -140,22 +157,26 @@ func (s *Service) List(pr resolver.Identity) ([]Thread, error) {
 		Repository *struct {
 			PullRequest *struct {
 				ReviewThreads struct {
-					Nodes []struct {
-						ID         string `json:"id"`
-						Path       string `json:"path"`
-						Line       *int   `json:"line"`
-						StartLine  *int   `json:"startLine"`
-						IsResolved bool   `json:"isResolved"`
-						IsOutdated bool   `json:"isOutdated"`
-						Comments   struct {
-							Nodes []struct {
-								ID        string `json:"id"`
-								Body      string `json:"body"`
-								CreatedAt string `json:"createdAt"`
-								URL       string `json:"url"`
-								Author    *struct {
-									Login string `json:"login"`
-								} `json:"author"`
+					Nodes []*struct {
+						ID                string `json:"id"`
+						Path              string `json:"path"`
+						Line              *int   `json:"line"`
+						StartLine         *int   `json:"startLine"`
+						OriginalLine      *int   `json:"originalLine"`
+						OriginalStartLine *int   `json:"originalStartLine"`
+						IsResolved        bool   `json:"isResolved"`
+						IsOutdated        bool   `json:"isOutdated"`
+						Comments          struct {
+							Nodes []*struct {
+								ID                string     `json:"id"`
+								Body              string     `json:"body"`
+								CreatedAt         string     `json:"createdAt"`
+								URL               string     `json:"url"`
+								IsMinimized       bool       `json:"isMinimized"`
+								MinimizedReason   *string    `json:"minimizedReason"`
+								DiffHunk          string     `json:"diffHunk"`
+								AuthorAssociation string     `json:"authorAssociation"`
+								Author            *actorNode `json:"author"`
 							} `json:"nodes"`
 						} `json:"comments"`
 					} `json:"nodes"`
@@ -164,91 +185,83 @@ func (s *Service) List(pr resolver.Identity) ([]Thread, error) {
 		} `json:"repository"`
 	}
 
-	if err := s.API.GraphQL(listThreadsQuery, variables, &response); err != nil {
-		return nil, err
+	queryErr := s.API.GraphQL(listThreadsQuery, variables, &response)
+	warnings, err := partialWarnings(queryErr)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if response.Repository == nil || response.Repository.PullRequest == nil {
-		return nil, errors.New("pull request not found or inaccessible")
+		if queryErr != nil {
+			// Keep the typed error (e.g. NOT_FOUND or FORBIDDEN) inspectable.
+			return nil, nil, fmt.Errorf("pull request not found or inaccessible: %w", queryErr)
+		}
+		return nil, nil, errors.New("pull request not found or inaccessible")
 	}
 
 	nodes := response.Repository.PullRequest.ReviewThreads.Nodes
 	threads := make([]Thread, 0, len(nodes))
 	for _, node := range nodes {
+		// Threads and comments GitHub could not return are null.
+		if node == nil {
+			continue
+		}
 		thread := Thread{
-			ID:         node.ID,
-			Path:       node.Path,
-			Line:       node.Line,
-			StartLine:  node.StartLine,
-			IsResolved: node.IsResolved,
-			IsOutdated: node.IsOutdated,
-			Comments:   make([]Comment, 0, len(node.Comments.Nodes)),
+			ID:                node.ID,
+			Path:              node.Path,
+			Line:              node.Line,
+			StartLine:         node.StartLine,
+			OriginalLine:      node.OriginalLine,
+			OriginalStartLine: node.OriginalStartLine,
+			IsResolved:        node.IsResolved,
+			IsOutdated:        node.IsOutdated,
+			Comments:          make([]Comment, 0, len(node.Comments.Nodes)),
 		}
 
 		for _, c := range node.Comments.Nodes {
-			if c.Author == nil || strings.TrimSpace(c.Author.Login) == "" {
-				return nil, errors.New("comment missing author")
+			if c == nil {
+				continue
+			}
+			if len(thread.Comments) == 0 {
+				thread.DiffHunk = c.DiffHunk
 			}
 			thread.Comments = append(thread.Comments, Comment{
-				ID:        c.ID,
-				Body:      c.Body,
-				Author:    c.Author.Login,
-				CreatedAt: c.CreatedAt,
-				URL:       c.URL,
+				ID:              c.ID,
+				Body:            c.Body,
+				Author:          newAuthor(c.Author, c.AuthorAssociation),
+				CreatedAt:       c.CreatedAt,
+				URL:             c.URL,
+				IsMinimized:     c.IsMinimized,
+				MinimizedReason: normalizeMinimizedReason(c.MinimizedReason),
 			})
 		}
 
 		threads = append(threads, thread)
 	}
 
-	return threads, nil
+	return threads, warnings, nil
 }
 
 // Create opens a new inline review thread with one comment on the given PR.
 func (s *Service) Create(pr resolver.Identity, input CreateInput) (CreateResult, error) {
-	path := strings.TrimSpace(input.Path)
-	body := strings.TrimSpace(input.Body)
-	if path == "" {
-		return CreateResult{}, errors.New("path is required")
-	}
-	if input.Line <= 0 {
-		return CreateResult{}, errors.New("line must be greater than zero")
-	}
-	if body == "" {
-		return CreateResult{}, errors.New("body is required")
+	if _, _, err := threadInput(input); err != nil {
+		return CreateResult{}, err
 	}
-
-	side, err := normalizeSide(input.Side)
+	prID, err := s.PullRequestID(pr)
 	if err != nil {
 		return CreateResult{}, err
 	}
+	return s.CreateWithPR(prID, input)
+}
 
-	prID, err := s.pullRequestNodeID(pr)
+// CreateWithPR is Create for a pull request whose node ID is already known, so
+// batches of threads resolve the pull request only once.
+func (s *Service) CreateWithPR(prID string, input CreateInput) (CreateResult, error) {
+	mutationInput, side, err := threadInput(input)
 	if err != nil {
 		return CreateResult{}, err
 	}
-
-	mutationInput := map[string]interface{}{
-		"pullRequestId": prID,
-		"path":          path,
-		"line":          input.Line,
-		"side":          side,
-		"body":          body,
-	}
-
-	if input.StartLine != nil {
-		if *input.StartLine <= 0 {
-			return CreateResult{}, errors.New("start-line must be greater than zero")
-		}
-		mutationInput["startLine"] = *input.StartLine
-	}
-	if input.StartSide != nil {
-		normalizedStartSide, err := normalizeSide(*input.StartSide)
-		if err != nil {
-			return CreateResult{}, fmt.Errorf("invalid start-side: %w", err)
-		}
-		mutationInput["startSide"] = normalizedStartSide
-	}
+	mutationInput["pullRequestId"] = prID
 
 	var response struct {
 		AddPullRequestReviewThread struct {
@@ -261,13 +274,12 @@ func (s *Service) Create(pr resolver.Identity, input CreateInput) (CreateResult,
 				IsOutdated bool   `json:"isOutdated"`
 				Comments   struct {
 					Nodes []struct {
-						ID        string `json:"id"`
-						Body      string `json:"body"`
-						CreatedAt string `json:"createdAt"`
-						URL       string `json:"url"`
-						Author    *struct {
-							Login string `json:"login"`
-						} `json:"author"`
+						ID                string     `json:"id"`
+						Body              string     `json:"body"`
+						CreatedAt         string     `json:"createdAt"`
+						URL               string     `json:"url"`
+						AuthorAssociation string     `json:"authorAssociation"`
+						Author            *actorNode `json:"author"`
 					} `json:"nodes"`
 				} `json:"comments"`
 			} `json:"thread"`
@@ -287,27 +299,70 @@ func (s *Service) Create(pr resolver.Identity, input CreateInput) (CreateResult,
 	}
 
 	comment := thread.Comments.Nodes[0]
-	if comment.Author == nil || strings.TrimSpace(comment.Author.Login) == "" {
-		return CreateResult{}, errors.New("create response missing comment author")
-	}
 
 	return CreateResult{
-		ThreadID:    thread.ID,
-		CommentID:   comment.ID,
-		Path:        thread.Path,
-		Line:        thread.Line,
-		StartLine:   thread.StartLine,
-		Author:      comment.Author.Login,
-		Body:        comment.Body,
-		CreatedAt:   comment.CreatedAt,
-		URL:         comment.URL,
-		IsResolved:  thread.IsResolved,
-		IsOutdated:  thread.IsOutdated,
-		RequestedOn: side,
+		ThreadID:      thread.ID,
+		CommentID:     comment.ID,
+		Path:          thread.Path,
+		Line:          thread.Line,
+		StartLine:     thread.StartLine,
+		Author:        newAuthor(comment.Author, comment.AuthorAssociation),
+		Body:          comment.Body,
+		CreatedAt:     comment.CreatedAt,
+		URL:           comment.URL,
+		IsResolved:    thread.IsResolved,
+		IsOutdated:    thread.IsOutdated,
+		RequestedSide: side,
 	}, nil
 }
 
-func (s *Service) pullRequestNodeID(pr resolver.Identity) (string, error) {
+// threadInput validates a CreateInput and converts it into the fields shared by
+// AddPullRequestReviewThreadInput and DraftPullRequestReviewThread, returning the
+// normalized side alongside.
+func threadInput(input CreateInput) (map[string]interface{}, string, error) {
+	path := strings.TrimSpace(input.Path)
+	body := strings.TrimSpace(input.Body)
+	if path == "" {
+		return nil, "", errors.New("path is required")
+	}
+	if input.Line <= 0 {
+		return nil, "", errors.New("line must be greater than zero")
+	}
+	if body == "" {
+		return nil, "", errors.New("body is required")
+	}
+
+	side, err := normalizeSide(input.Side)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fields := map[string]interface{}{
+		"path": path,
+		"line": input.Line,
+		"side": side,
+		"body": body,
+	}
+
+	if input.StartLine != nil {
+		if *input.StartLine <= 0 {
+			return nil, "", errors.New("start-line must be greater than zero")
+		}
+		fields["startLine"] = *input.StartLine
+	}
+	if input.StartSide != nil {
+		normalizedStartSide, err := normalizeSide(*input.StartSide)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid start-side: %w", err)
+		}
+		fields["startSide"] = normalizedStartSide
+	}
+
+	return fields, side, nil
+}
+
+// PullRequestID looks up the GraphQL node ID of a pull request.
+func (s *Service) PullRequestID(pr resolver.Identity) (string, error) {
 	variables := map[string]interface{}{
 		"owner":  pr.Owner,
 		"name":   pr.Repo,
@@ -0,0 +1,140 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/agynio/gh-pr-review/internal/ghfake"
+	"github.com/agynio/gh-pr-review/internal/resolver"
+)
+
+const testPatch = "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"\n \n func main() {}"
+
+func newFakeService(t *testing.T) (*Service, resolver.Identity) {
+	t.Helper()
+	backend := ghfake.New("github.com", "octocat")
+	backend.AddPullRequest(&ghfake.PullRequest{
+		Owner:   "acme",
+		Repo:    "widgets",
+		Number:  7,
+		Title:   "Add widget",
+		Author:  "alice",
+		HeadSHA: "abc1234def",
+		Files:   []ghfake.File{{Filename: "main.go", Patch: testPatch}},
+	})
+	pr := resolver.Identity{Owner: "acme", Repo: "widgets", Host: "github.com", Number: 7, URL: "https://github.com/acme/widgets/pull/7"}
+	return NewService(backend.Client()), pr
+}
+
+func createThread(t *testing.T, service *Service, pr resolver.Identity) CreateResult {
+	t.Helper()
+	created, err := service.Create(pr, CreateInput{Path: "main.go", Line: 2, Side: "RIGHT", Body: "Why fmt?"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return created
+}
+
+func TestCreateAddsThread(t *testing.T) {
+	service, pr := newFakeService(t)
+
+	created := createThread(t, service, pr)
+	if created.ThreadID == "" || created.CommentID == "" {
+		t.Fatalf("missing ids: %+v", created)
+	}
+	if created.Path != "main.go" || created.Line == nil || *created.Line != 2 {
+		t.Fatalf("unexpected placement: %+v", created)
+	}
+	if created.Author.Login != "octocat" || created.Author.Type != AuthorUser {
+		t.Fatalf("author = %+v, want the user octocat", created.Author)
+	}
+
+	threads, warnings, err := service.List(pr)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if len(threads) != 1 || threads[0].ID != created.ThreadID {
+		t.Fatalf("threads = %+v, want the created thread", threads)
+	}
+}
+
+func TestCreateRejectsLineOutsideDiff(t *testing.T) {
+	service, pr := newFakeService(t)
+
+	if _, err := service.Create(pr, CreateInput{Path: "main.go", Line: 40, Side: "RIGHT", Body: "?"}); err == nil {
+		t.Fatal("expected an error for a line outside the diff")
+	}
+}
+
+func TestReplyAppendsComment(t *testing.T) {
+	service, pr := newFakeService(t)
+	created := createThread(t, service, pr)
+
+	reply, err := service.Reply(created.ThreadID, "Needed for Println")
+	if err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	if reply.Body != "Needed for Println" || reply.Author.Login != "octocat" {
+		t.Fatalf("unexpected reply: %+v", reply)
+	}
+
+	threads, _, err := service.List(pr)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(threads) != 1 || len(threads[0].Comments) != 2 {
+		t.Fatalf("threads = %+v, want one thread with two comments", threads)
+	}
+}
+
+func TestResolveAndUnresolve(t *testing.T) {
+	service, pr := newFakeService(t)
+	created := createThread(t, service, pr)
+
+	resolved, err := service.Resolve(created.ThreadID)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if resolved.ID != created.ThreadID || !resolved.IsResolved {
+		t.Fatalf("unexpected resolve result: %+v", resolved)
+	}
+
+	unresolved, err := service.Unresolve(created.ThreadID)
+	if err != nil {
+		t.Fatalf("unresolve: %v", err)
+	}
+	if unresolved.IsResolved {
+		t.Fatalf("thread still resolved: %+v", unresolved)
+	}
+
+	if _, err := service.Resolve("PRRT_missing"); err == nil {
+		t.Fatal("expected an error for an unknown thread")
+	}
+}
+
+func TestMinimizeHidesComment(t *testing.T) {
+	service, pr := newFakeService(t)
+	created := createThread(t, service, pr)
+
+	minimized, err := service.Minimize(created.CommentID, "outdated")
+	if err != nil {
+		t.Fatalf("minimize: %v", err)
+	}
+	if !minimized.IsMinimized || minimized.MinimizedReason != "outdated" {
+		t.Fatalf("unexpected minimize result: %+v", minimized)
+	}
+
+	threads, _, err := service.List(pr)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(FilterMinimized(threads)) != 0 {
+		t.Fatalf("minimized comment was not filtered: %+v", threads)
+	}
+
+	if _, err := service.Minimize(created.CommentID, "bogus"); err == nil {
+		t.Fatal("expected an error for an unknown classifier")
+	}
+}
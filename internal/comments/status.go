@@ -0,0 +1,171 @@
+package comments
+
+import (
+	"sort"
+	"strings"
+)
+
+// Review states reported by GitHub.
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+	ReviewDismissed        = "DISMISSED"
+	ReviewPending          = "PENDING"
+)
+
+// ThreadCounts tallies review threads by state.
+type ThreadCounts struct {
+	Total      int `json:"total"`
+	Unresolved int `json:"unresolved"`
+	Resolved   int `json:"resolved"`
+	Outdated   int `json:"outdated"`
+}
+
+// AuthorThreadCounts tallies the threads started by one author.
+type AuthorThreadCounts struct {
+	Author Author       `json:"author"`
+	Counts ThreadCounts `json:"counts"`
+}
+
+// ReviewerDecision is the latest effective review state for one reviewer.
+type ReviewerDecision struct {
+	Author      Author `json:"author"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+	URL         string `json:"url"`
+}
+
+// ReviewCounts tallies reviewers by their latest decision.
+type ReviewCounts struct {
+	Approved         int `json:"approved"`
+	ChangesRequested int `json:"changes_requested"`
+	Commented        int `json:"commented"`
+	Dismissed        int `json:"dismissed"`
+}
+
+// Summary aggregates review state for a pull request.
+type Summary struct {
+	Threads           ThreadCounts            `json:"threads"`
+	UnresolvedAuthors int                     `json:"unresolved_authors"`
+	ByAuthor          []AuthorThreadCounts    `json:"by_author"`
+	ByFile            map[string]ThreadCounts `json:"by_file"`
+	Reviews           ReviewCounts            `json:"reviews"`
+	Reviewers         []ReviewerDecision      `json:"reviewers"`
+}
+
+// Summarize computes thread counts by state, author and file, and the latest review
+// decision per reviewer. A thread is attributed to the author of its first comment;
+// authors are listed in the order their first thread appears.
+func Summarize(threads []Thread, reviews []Review) Summary {
+	summary := Summary{
+		ByAuthor:  make([]AuthorThreadCounts, 0),
+		ByFile:    make(map[string]ThreadCounts),
+		Reviewers: make([]ReviewerDecision, 0),
+	}
+
+	authorIndex := make(map[string]int)
+	for _, thread := range threads {
+		author := newAuthor(nil, "")
+		if len(thread.Comments) > 0 {
+			author = thread.Comments[0].Author
+		}
+		i, seen := authorIndex[author.Login]
+		if !seen {
+			i = len(summary.ByAuthor)
+			authorIndex[author.Login] = i
+			summary.ByAuthor = append(summary.ByAuthor, AuthorThreadCounts{Author: author})
+		}
+
+		summary.Threads = tallyThread(summary.Threads, thread)
+		summary.ByAuthor[i].Counts = tallyThread(summary.ByAuthor[i].Counts, thread)
+		summary.ByFile[thread.Path] = tallyThread(summary.ByFile[thread.Path], thread)
+	}
+	for _, entry := range summary.ByAuthor {
+		if entry.Counts.Unresolved > 0 {
+			summary.UnresolvedAuthors++
+		}
+	}
+
+	summary.Reviewers = latestDecisions(reviews)
+	for _, decision := range summary.Reviewers {
+		switch decision.State {
+		case ReviewApproved:
+			summary.Reviews.Approved++
+		case ReviewChangesRequested:
+			summary.Reviews.ChangesRequested++
+		case ReviewCommented:
+			summary.Reviews.Commented++
+		case ReviewDismissed:
+			summary.Reviews.Dismissed++
+		}
+	}
+
+	return summary
+}
+
+// FilterUnresolved keeps only threads that have not been resolved.
+func FilterUnresolved(threads []Thread) []Thread {
+	filtered := make([]Thread, 0, len(threads))
+	for _, thread := range threads {
+		if !thread.IsResolved {
+			filtered = append(filtered, thread)
+		}
+	}
+	return filtered
+}
+
+func tallyThread(counts ThreadCounts, thread Thread) ThreadCounts {
+	counts.Total++
+	if thread.IsResolved {
+		counts.Resolved++
+	} else {
+		counts.Unresolved++
+	}
+	if thread.IsOutdated {
+		counts.Outdated++
+	}
+	return counts
+}
+
+// latestDecisions mirrors GitHub's review decision semantics: a reviewer's latest
+// approval, change request or dismissal wins over later plain comments, and pending
+// reviews are ignored.
+func latestDecisions(reviews []Review) []ReviewerDecision {
+	sorted := make([]Review, 0, len(reviews))
+	for _, r := range reviews {
+		if r.SubmittedAt == "" || strings.EqualFold(r.State, ReviewPending) {
+			continue
+		}
+		sorted = append(sorted, r)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return timestampLess(sorted[i].SubmittedAt, sorted[j].SubmittedAt)
+	})
+
+	decisions := make(map[string]ReviewerDecision)
+	order := make([]string, 0)
+	for _, r := range sorted {
+		state := strings.ToUpper(r.State)
+		author := r.Author.Login
+		existing, seen := decisions[author]
+		if seen && state == ReviewCommented && existing.State != ReviewCommented {
+			continue
+		}
+		if !seen {
+			order = append(order, author)
+		}
+		decisions[author] = ReviewerDecision{
+			Author:      r.Author,
+			State:       state,
+			SubmittedAt: r.SubmittedAt,
+			URL:         r.URL,
+		}
+	}
+
+	result := make([]ReviewerDecision, 0, len(order))
+	for _, author := range order {
+		result = append(result, decisions[author])
+	}
+	return result
+}
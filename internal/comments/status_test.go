@@ -0,0 +1,83 @@
+package comments
+
+import (
+	"reflect"
+	"testing"
+)
+
+func statusThread(path, author string, resolved, outdated bool) Thread {
+	return Thread{
+		Path:       path,
+		IsResolved: resolved,
+		IsOutdated: outdated,
+		Comments:   []Comment{{Author: Author{Login: author, Type: AuthorUser}}, {Author: Author{Login: "octocat", Type: AuthorUser}}},
+	}
+}
+
+func TestSummarizeCountsThreads(t *testing.T) {
+	threads := []Thread{
+		statusThread("main.go", "bob", false, false),
+		statusThread("main.go", "bob", true, true),
+		statusThread("util.go", "carol", false, true),
+		statusThread("util.go", "dave", true, false),
+		{Path: "empty.go"},
+	}
+
+	summary := Summarize(threads, nil)
+	if want := (ThreadCounts{Total: 5, Unresolved: 3, Resolved: 2, Outdated: 2}); summary.Threads != want {
+		t.Fatalf("threads = %+v, want %+v", summary.Threads, want)
+	}
+	wantByAuthor := []AuthorThreadCounts{
+		{Author: Author{Login: "bob", Type: AuthorUser}, Counts: ThreadCounts{Total: 2, Unresolved: 1, Resolved: 1, Outdated: 1}},
+		{Author: Author{Login: "carol", Type: AuthorUser}, Counts: ThreadCounts{Total: 1, Unresolved: 1, Outdated: 1}},
+		{Author: Author{Login: "dave", Type: AuthorUser}, Counts: ThreadCounts{Total: 1, Resolved: 1}},
+		{Author: Author{Login: "ghost", Type: AuthorGhost}, Counts: ThreadCounts{Total: 1, Unresolved: 1}},
+	}
+	if !reflect.DeepEqual(summary.ByAuthor, wantByAuthor) {
+		t.Fatalf("by_author = %+v, want %+v", summary.ByAuthor, wantByAuthor)
+	}
+	wantByFile := map[string]ThreadCounts{
+		"main.go":  {Total: 2, Unresolved: 1, Resolved: 1, Outdated: 1},
+		"util.go":  {Total: 2, Unresolved: 1, Resolved: 1, Outdated: 1},
+		"empty.go": {Total: 1, Unresolved: 1},
+	}
+	if !reflect.DeepEqual(summary.ByFile, wantByFile) {
+		t.Fatalf("by_file = %+v, want %+v", summary.ByFile, wantByFile)
+	}
+	// bob, carol and the thread without comments still have unresolved threads.
+	if summary.UnresolvedAuthors != 3 {
+		t.Fatalf("unresolved_authors = %d, want 3", summary.UnresolvedAuthors)
+	}
+}
+
+func TestSummarizeKeepsLatestReviewDecision(t *testing.T) {
+	reviews := []Review{
+		{Author: Author{Login: "bob"}, State: ReviewChangesRequested, SubmittedAt: "2026-01-01T10:00:00Z"},
+		{Author: Author{Login: "carol"}, State: ReviewCommented, SubmittedAt: "2026-01-01T09:00:00Z"},
+		{Author: Author{Login: "bob"}, State: ReviewApproved, SubmittedAt: "2026-01-01T11:00:00Z"},
+		{Author: Author{Login: "bob"}, State: ReviewCommented, SubmittedAt: "2026-01-01T12:00:00Z"},
+		{Author: Author{Login: "carol"}, State: "changes_requested", SubmittedAt: "2026-01-01T09:30:00Z"},
+		{Author: Author{Login: "dave"}, State: ReviewPending},
+		{Author: Author{Login: "erin"}, State: ReviewDismissed, SubmittedAt: "2026-01-01T08:00:00Z"},
+	}
+
+	summary := Summarize(nil, reviews)
+	var got []string
+	for _, decision := range summary.Reviewers {
+		got = append(got, decision.Author.Login+"="+decision.State)
+	}
+	want := []string{"erin=DISMISSED", "carol=CHANGES_REQUESTED", "bob=APPROVED"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("reviewers = %v, want %v", got, want)
+	}
+	if want := (ReviewCounts{Approved: 1, ChangesRequested: 1, Dismissed: 1}); summary.Reviews != want {
+		t.Fatalf("reviews = %+v, want %+v", summary.Reviews, want)
+	}
+}
+
+func TestFilterUnresolved(t *testing.T) {
+	filtered := FilterUnresolved([]Thread{{ID: "open"}, {ID: "done", IsResolved: true}})
+	if len(filtered) != 1 || filtered[0].ID != "open" {
+		t.Fatalf("filtered = %+v, want only the open thread", filtered)
+	}
+}
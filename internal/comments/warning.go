@@ -0,0 +1,43 @@
+package comments
+
+import (
+	"fmt"
+
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+)
+
+// Warning reports part of a response GitHub could not return, such as a field
+// the viewer is not allowed to read.
+type Warning struct {
+	Type    string `json:"type,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	message := w.Message
+	if w.Type != "" {
+		message = fmt.Sprintf("%s: %s", w.Type, message)
+	}
+	if w.Path != "" {
+		message = fmt.Sprintf("%s (at %s)", message, w.Path)
+	}
+	return message
+}
+
+// partialWarnings turns the errors of a partial GraphQL response into warnings.
+// Any other error is returned unchanged.
+func partialWarnings(err error) ([]Warning, error) {
+	if err == nil {
+		return nil, nil
+	}
+	gqlErr, ok := ghcli.PartialGraphQLError(err)
+	if !ok {
+		return nil, err
+	}
+	warnings := make([]Warning, 0, len(gqlErr.Errors))
+	for _, entry := range gqlErr.Errors {
+		warnings = append(warnings, Warning{Type: entry.Kind(), Path: entry.PathString(), Message: entry.Message})
+	}
+	return warnings, nil
+}
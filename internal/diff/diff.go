@@ -0,0 +1,160 @@
+// Package diff parses unified diff hunks as returned by the GitHub API
+// (pull request file patches and review comment diff hunks).
+package diff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Line kinds within a hunk.
+const (
+	Context = ' '
+	Added   = '+'
+	Removed = '-'
+)
+
+var hunkHeaderRE = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Line is one line of a hunk. OldLine is zero for added lines and NewLine is
+// zero for removed lines.
+type Line struct {
+	Kind    byte
+	Text    string
+	OldLine int
+	NewLine int
+}
+
+// Hunk is one `@@` section of a unified diff.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// File pairs a path with its parsed hunks.
+type File struct {
+	Path  string
+	Hunks []Hunk
+}
+
+// Parse parses a unified diff patch. Content before the first hunk header and
+// "\ No newline at end of file" markers are ignored.
+func Parse(patch string) []Hunk {
+	hunks := make([]Hunk, 0)
+	var current *Hunk
+	oldLine, newLine := 0, 0
+
+	for _, raw := range strings.Split(patch, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		if matches := hunkHeaderRE.FindStringSubmatch(raw); matches != nil {
+			hunks = append(hunks, Hunk{
+				OldStart: atoi(matches[1], 0),
+				OldLines: atoi(matches[2], 1),
+				NewStart: atoi(matches[3], 0),
+				NewLines: atoi(matches[4], 1),
+			})
+			current = &hunks[len(hunks)-1]
+			oldLine, newLine = current.OldStart, current.NewStart
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(raw, `\`) {
+			continue
+		}
+
+		kind := byte(Context)
+		text := raw
+		if raw != "" {
+			kind = raw[0]
+			text = raw[1:]
+		}
+
+		switch kind {
+		case Added:
+			current.Lines = append(current.Lines, Line{Kind: Added, Text: text, NewLine: newLine})
+			newLine++
+		case Removed:
+			current.Lines = append(current.Lines, Line{Kind: Removed, Text: text, OldLine: oldLine})
+			oldLine++
+		case Context:
+			if !current.inBounds(oldLine, newLine) {
+				continue
+			}
+			current.Lines = append(current.Lines, Line{Kind: Context, Text: text, OldLine: oldLine, NewLine: newLine})
+			oldLine++
+			newLine++
+		}
+	}
+
+	return hunks
+}
+
+// inBounds reports whether another context line still belongs to the hunk,
+// which guards against a trailing empty line produced by splitting the patch.
+func (h *Hunk) inBounds(oldLine, newLine int) bool {
+	return oldLine < h.OldStart+h.OldLines || newLine < h.NewStart+h.NewLines
+}
+
+// SideLines returns the lines visible on one side of the diff ("LEFT" for the
+// base version, "RIGHT" for the head version) in order.
+func SideLines(hunks []Hunk, side string) []Line {
+	lines := make([]Line, 0)
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			if LineNumber(l, side) > 0 {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// LineNumber returns the line's number on the given side, or zero when the
+// line does not exist on that side.
+func LineNumber(l Line, side string) int {
+	if strings.EqualFold(side, "LEFT") {
+		return l.OldLine
+	}
+	return l.NewLine
+}
+
+// Contains reports whether a line number on the given side falls inside any hunk.
+func Contains(hunks []Hunk, side string, line int) bool {
+	for _, l := range SideLines(hunks, side) {
+		if LineNumber(l, side) == line {
+			return true
+		}
+	}
+	return false
+}
+
+// TailLines returns the last n lines of a review comment diff hunk on the given
+// side. GitHub ends a comment's diff hunk at the commented line, so these are
+// the lines the comment was anchored to.
+func TailLines(hunk string, side string, n int) []Line {
+	lines := SideLines(Parse(hunk), side)
+	if n <= 0 || len(lines) == 0 {
+		return nil
+	}
+	if n > len(lines) {
+		n = len(lines)
+	}
+	return lines[len(lines)-n:]
+}
+
+func atoi(value string, fallback int) int {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
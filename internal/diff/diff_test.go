@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []Hunk
+	}{
+		{
+			name:  "single hunk",
+			patch: "@@ -1,3 +1,4 @@\n package main\n+import \"fmt\"\n \n func main() {}",
+			want: []Hunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 4, Lines: []Line{
+				{Kind: Context, Text: "package main", OldLine: 1, NewLine: 1},
+				{Kind: Added, Text: `import "fmt"`, NewLine: 2},
+				{Kind: Context, Text: "", OldLine: 2, NewLine: 3},
+				{Kind: Context, Text: "func main() {}", OldLine: 3, NewLine: 4},
+			}}},
+		},
+		{
+			name:  "counts default to one",
+			patch: "@@ -5 +5 @@\n-old\n+new",
+			want: []Hunk{{OldStart: 5, OldLines: 1, NewStart: 5, NewLines: 1, Lines: []Line{
+				{Kind: Removed, Text: "old", OldLine: 5},
+				{Kind: Added, Text: "new", NewLine: 5},
+			}}},
+		},
+		{
+			name:  "preamble, crlf, no-newline marker and trailing newline",
+			patch: "diff --git a/x b/x\n--- a/x\n+++ b/x\r\n@@ -1,2 +1,2 @@ func x()\r\n a\r\n-b\n\\ No newline at end of file\n+B\n\\ No newline at end of file\n",
+			want: []Hunk{{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []Line{
+				{Kind: Context, Text: "a", OldLine: 1, NewLine: 1},
+				{Kind: Removed, Text: "b", OldLine: 2},
+				{Kind: Added, Text: "B", NewLine: 2},
+			}}},
+		},
+		{
+			name:  "multiple hunks",
+			patch: "@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -10,2 +10,3 @@\n x\n+y\n z",
+			want: []Hunk{
+				{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []Line{
+					{Kind: Removed, Text: "a", OldLine: 1},
+					{Kind: Added, Text: "A", NewLine: 1},
+					{Kind: Context, Text: "b", OldLine: 2, NewLine: 2},
+				}},
+				{OldStart: 10, OldLines: 2, NewStart: 10, NewLines: 3, Lines: []Line{
+					{Kind: Context, Text: "x", OldLine: 10, NewLine: 10},
+					{Kind: Added, Text: "y", NewLine: 11},
+					{Kind: Context, Text: "z", OldLine: 11, NewLine: 12},
+				}},
+			},
+		},
+		{
+			name:  "pure insertion and deletion",
+			patch: "@@ -0,0 +1,2 @@\n+a\n+b\n@@ -7,1 +8,0 @@\n-gone",
+			want: []Hunk{
+				{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: []Line{
+					{Kind: Added, Text: "a", NewLine: 1},
+					{Kind: Added, Text: "b", NewLine: 2},
+				}},
+				{OldStart: 7, OldLines: 1, NewStart: 8, NewLines: 0, Lines: []Line{
+					{Kind: Removed, Text: "gone", OldLine: 7},
+				}},
+			},
+		},
+		{name: "no hunks", patch: "Binary files differ", want: []Hunk{}},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.patch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Parse() =\n  %+v\nwant\n  %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMapLine(t *testing.T) {
+	// Inserts a line after old line 2, removes old line 11, inserts two lines
+	// after old line 15 and removes old lines 18-19.
+	hunks := Parse("@@ -2,3 +2,4 @@\n l2\n+new\n l3\n l4\n" +
+		"@@ -10,3 +11,2 @@\n l10\n-l11\n l12\n" +
+		"@@ -15,0 +16,2 @@\n+x\n+y\n" +
+		"@@ -18,2 +19,0 @@\n-l18\n-l19")
+
+	tests := []struct {
+		old  int
+		want int
+	}{
+		{old: 1, want: 1},
+		{old: 2, want: 2},
+		{old: 3, want: 4},
+		{old: 4, want: 5},
+		{old: 5, want: 6},
+		{old: 9, want: 10},
+		{old: 10, want: 11},
+		{old: 11, want: 0},
+		{old: 12, want: 12},
+		{old: 15, want: 15},
+		{old: 16, want: 18},
+		{old: 17, want: 19},
+		{old: 18, want: 0},
+		{old: 19, want: 0},
+		{old: 20, want: 20},
+	}
+	for _, tt := range tests {
+		if got := MapLine(hunks, tt.old); got != tt.want {
+			t.Errorf("MapLine(%d) = %d, want %d", tt.old, got, tt.want)
+		}
+	}
+	if got := MapLine(nil, 42); got != 42 {
+		t.Errorf("MapLine without hunks = %d, want 42", got)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	const hunk = "@@ -1,4 +1,4 @@\n package main\n-import \"os\"\n+import \"fmt\"\n \n func main() {}"
+
+	tests := []struct {
+		name  string
+		hunk  string
+		side  string
+		n     int
+		texts []string
+		lines []int
+	}{
+		{name: "right, last line", hunk: hunk, side: "RIGHT", n: 1, texts: []string{"func main() {}"}, lines: []int{4}},
+		{name: "right, range", hunk: hunk, side: "RIGHT", n: 3, texts: []string{`import "fmt"`, "", "func main() {}"}, lines: []int{2, 3, 4}},
+		{name: "left skips added lines", hunk: hunk, side: "left", n: 3, texts: []string{`import "os"`, "", "func main() {}"}, lines: []int{2, 3, 4}},
+		{name: "more than available", hunk: hunk, side: "RIGHT", n: 10, texts: []string{"package main", `import "fmt"`, "", "func main() {}"}, lines: []int{1, 2, 3, 4}},
+		{name: "ends on a removed line", hunk: "@@ -1,2 +1,1 @@\n a\n-b", side: "LEFT", n: 1, texts: []string{"b"}, lines: []int{2}},
+		{name: "zero lines", hunk: hunk, side: "RIGHT", n: 0},
+		{name: "empty hunk", hunk: "", side: "RIGHT", n: 1},
+	}
+	for _, tt := range tests {
+		got := TailLines(tt.hunk, tt.side, tt.n)
+		var texts []string
+		var lines []int
+		for _, l := range got {
+			texts = append(texts, l.Text)
+			lines = append(lines, LineNumber(l, tt.side))
+		}
+		if !reflect.DeepEqual(texts, tt.texts) || !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("%s: TailLines() = %q at %v, want %q at %v", tt.name, texts, lines, tt.texts, tt.lines)
+		}
+	}
+}
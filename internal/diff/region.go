@@ -0,0 +1,86 @@
+package diff
+
+// Region describes how a range of old-file lines fared in a diff.
+type Region struct {
+	// Changed is set when a line in the range was removed or lines were
+	// inserted between its first and last line.
+	Changed bool
+	// NewStart and NewEnd locate the range in the new file; each is zero when
+	// that line was removed.
+	NewStart int
+	NewEnd   int
+	// Hunks are the hunks that touch the range.
+	Hunks []Hunk
+}
+
+// TraceRegion follows old-file lines start..end through a diff.
+func TraceRegion(hunks []Hunk, start, end int) Region {
+	if end < start {
+		start, end = end, start
+	}
+	region := Region{
+		NewStart: MapLine(hunks, start),
+		NewEnd:   MapLine(hunks, end),
+	}
+
+	for _, h := range hunks {
+		touched := false
+		prevOld := h.OldStart - 1
+		if h.OldLines == 0 {
+			prevOld = h.OldStart
+		}
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case Removed:
+				if l.OldLine >= start && l.OldLine <= end {
+					region.Changed = true
+					touched = true
+				}
+				prevOld = l.OldLine
+			case Added:
+				if prevOld >= start && prevOld < end {
+					region.Changed = true
+					touched = true
+				}
+			default:
+				if l.OldLine >= start && l.OldLine <= end {
+					touched = true
+				}
+				prevOld = l.OldLine
+			}
+		}
+		if touched {
+			region.Hunks = append(region.Hunks, h)
+		}
+	}
+	return region
+}
+
+// MapLine returns the new-file number of an old-file line, or zero when the
+// line was removed.
+func MapLine(hunks []Hunk, old int) int {
+	delta := 0
+	for _, h := range hunks {
+		nextOld, nextNew := h.OldStart+h.OldLines, h.NewStart+h.NewLines
+		if h.OldLines == 0 {
+			nextOld++
+		}
+		if h.NewLines == 0 {
+			nextNew++
+		}
+		if old >= nextOld {
+			delta = nextNew - nextOld
+			continue
+		}
+		if old < h.OldStart || (h.OldLines == 0 && old <= h.OldStart) {
+			break
+		}
+		for _, l := range h.Lines {
+			if l.OldLine == old {
+				return l.NewLine
+			}
+		}
+		return 0
+	}
+	return old + delta
+}
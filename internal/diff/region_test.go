@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTraceRegion(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      string
+		start, end int
+		want       Region
+		hunkStarts []int
+	}{
+		{
+			name:  "lines added above",
+			patch: "@@ -1,2 +1,4 @@\n a\n+x\n+y\n b",
+			start: 5, end: 6,
+			want: Region{NewStart: 7, NewEnd: 8},
+		},
+		{
+			name:  "lines removed above",
+			patch: "@@ -1,3 +1,1 @@\n a\n-b\n-c",
+			start: 5, end: 6,
+			want: Region{NewStart: 3, NewEnd: 4},
+		},
+		{
+			name:  "line edited inside",
+			patch: "@@ -4,3 +4,3 @@\n d\n-e\n+E\n f",
+			start: 4, end: 6,
+			want:       Region{Changed: true, NewStart: 4, NewEnd: 6},
+			hunkStarts: []int{4},
+		},
+		{
+			name:  "region deleted",
+			patch: "@@ -3,4 +3,1 @@\n c\n-d\n-e\n-f",
+			start: 4, end: 6,
+			want:       Region{Changed: true},
+			hunkStarts: []int{3},
+		},
+		{
+			name:  "line inserted inside",
+			patch: "@@ -4,2 +4,3 @@\n d\n+x\n e",
+			start: 4, end: 5,
+			want:       Region{Changed: true, NewStart: 4, NewEnd: 6},
+			hunkStarts: []int{4},
+		},
+		{
+			name:  "line inserted after the last line",
+			patch: "@@ -4,2 +4,3 @@\n d\n+x\n e",
+			start: 3, end: 4,
+			want:       Region{NewStart: 3, NewEnd: 4},
+			hunkStarts: []int{4},
+		},
+		{
+			name:  "pure insertion hunk inside",
+			patch: "@@ -5,0 +6,2 @@\n+x\n+y",
+			start: 4, end: 6,
+			want:       Region{Changed: true, NewStart: 4, NewEnd: 8},
+			hunkStarts: []int{5},
+		},
+		{
+			name:  "spans two unchanged hunks",
+			patch: "@@ -2,2 +2,3 @@\n b\n+x\n c\n@@ -10,2 +11,1 @@\n j\n-k",
+			start: 3, end: 10,
+			want:       Region{NewStart: 4, NewEnd: 11},
+			hunkStarts: []int{2, 10},
+		},
+		{
+			name:  "removed line in the second hunk",
+			patch: "@@ -2,2 +2,3 @@\n b\n+x\n c\n@@ -10,2 +11,1 @@\n j\n-k",
+			start: 11, end: 11,
+			want:       Region{Changed: true},
+			hunkStarts: []int{10},
+		},
+		{
+			name:  "reversed bounds",
+			patch: "@@ -4,3 +4,3 @@\n d\n-e\n+E\n f",
+			start: 6, end: 4,
+			want:       Region{Changed: true, NewStart: 4, NewEnd: 6},
+			hunkStarts: []int{4},
+		},
+	}
+
+	for _, tt := range tests {
+		got := TraceRegion(Parse(tt.patch), tt.start, tt.end)
+		var starts []int
+		for _, h := range got.Hunks {
+			starts = append(starts, h.OldStart)
+		}
+		got.Hunks = nil
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(starts, tt.hunkStarts) {
+			t.Errorf("%s: TraceRegion(%d, %d) = %+v with hunks at %v, want %+v with hunks at %v",
+				tt.name, tt.start, tt.end, got, starts, tt.want, tt.hunkStarts)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ghcli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ConditionalREST is implemented by clients that can revalidate REST responses with ETags.
+type ConditionalREST interface {
+	RESTConditional(method, path string, params map[string]string, etag string) (body []byte, newETag string, notModified bool, err error)
+}
+
+// Cache is an API decorator that stores read responses on disk.
+//
+// GraphQL queries and REST GET requests without a body are cached per host and
+// credential identity, keyed by the request and its variables, so accounts
+// sharing a machine never see each other's responses. Entries younger than TTL are served
+// without a network call; older REST entries are revalidated with If-None-Match
+// when the wrapped client supports it. Any mutation or non-GET REST call clears
+// the host's entries so subsequent reads observe the change. Entries are
+// written atomically, so concurrent processes can share the directory.
+type Cache struct {
+	API      API
+	Host     string
+	Identity string
+	Dir      string
+	TTL      time.Duration
+
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	ETag     string          `json:"etag,omitempty"`
+	Body     json.RawMessage `json:"body"`
+}
+
+var mutationRE = regexp.MustCompile(`^\s*mutation\b`)
+
+// NewCache wraps api with a disk cache rooted at dir. identity distinguishes the
+// credentials the requests are made with; see CredentialIdentity.
+func NewCache(api API, host, identity, dir string, ttl time.Duration) *Cache {
+	return &Cache{API: api, Host: host, Identity: identity, Dir: dir, TTL: ttl, now: time.Now}
+}
+
+// CredentialIdentity returns an opaque identifier for the token gh uses with
+// host, including GH_TOKEN and GITHUB_TOKEN overrides. Only a hash of the token
+// is kept, so it is safe to use in cache keys.
+func CredentialIdentity(host string) (string, error) {
+	args := []string{"auth", "token"}
+	if host != "" {
+		args = append(args, "--hostname", host)
+	}
+	stdout, stderr, err := runGh(args, nil)
+	if err != nil {
+		return "", wrapError(err, nil, stderr)
+	}
+	token := strings.TrimSpace(string(stdout))
+	if token == "" {
+		return "", fmt.Errorf("no gh token for %s", host)
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DefaultCacheDir returns the per-user cache directory for this extension.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locate user cache dir: %w", err)
+	}
+	return filepath.Join(base, "gh-pr-comments"), nil
+}
+
+// ClearCache removes every cached entry under dir.
+func ClearCache(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clear cache: %w", err)
+	}
+	return nil
+}
+
+// REST serves GET requests from the cache and forwards everything else.
+func (c *Cache) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	if !strings.EqualFold(method, "GET") || body != nil {
+		c.invalidate()
+		return c.API.REST(method, path, params, body, result)
+	}
+
+	key := c.key("rest", method, path, params)
+	entry, fresh := c.load(key)
+	if fresh {
+		return decodeCached(entry.Body, result)
+	}
+
+	if conditional, ok := c.API.(ConditionalREST); ok {
+		etag := ""
+		if entry != nil {
+			etag = entry.ETag
+		}
+		data, newETag, notModified, err := conditional.RESTConditional(method, path, params, etag)
+		if err != nil {
+			return err
+		}
+		if notModified && entry != nil {
+			entry.StoredAt = c.now()
+			c.store(key, entry)
+			return decodeCached(entry.Body, result)
+		}
+		c.store(key, &cacheEntry{StoredAt: c.now(), ETag: newETag, Body: data})
+		return decodeCached(data, result)
+	}
+
+	var raw json.RawMessage
+	if err := c.API.REST(method, path, params, nil, &raw); err != nil {
+		return err
+	}
+	c.store(key, &cacheEntry{StoredAt: c.now(), Body: raw})
+	return decodeCached(raw, result)
+}
+
+// GraphQL serves queries from the cache and forwards mutations.
+func (c *Cache) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	if mutationRE.MatchString(query) {
+		c.invalidate()
+		return c.API.GraphQL(query, variables, result)
+	}
+
+	key := c.key("graphql", query, variables)
+	if entry, fresh := c.load(key); fresh {
+		return decodeCached(entry.Body, result)
+	}
+
+	var raw json.RawMessage
+	err := c.API.GraphQL(query, variables, &raw)
+	if _, partial := PartialGraphQLError(err); partial {
+		// Serve partial data without caching it, so the next read retries.
+		return decodePartial(raw, result, err)
+	}
+	if err != nil {
+		return err
+	}
+	c.store(key, &cacheEntry{StoredAt: c.now(), Body: raw})
+	return decodeCached(raw, result)
+}
+
+func (c *Cache) hostDir() string {
+	host := strings.TrimSpace(c.Host)
+	if host == "" {
+		host = "default"
+	}
+	return filepath.Join(c.Dir, host)
+}
+
+func (c *Cache) key(parts ...interface{}) string {
+	data, err := json.Marshal(append([]interface{}{c.Host, c.Identity}, parts...))
+	if err != nil {
+		data = []byte(fmt.Sprint(parts...))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// load returns the stored entry (if any) and whether it is still within TTL.
+func (c *Cache) load(key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(c.hostDir(), key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, c.now().Sub(entry.StoredAt) < c.TTL
+}
+
+// store writes an entry through a temp file and rename so readers never see a
+// partial file. Failures are ignored: the cache is best effort.
+func (c *Cache) store(key string, entry *cacheEntry) {
+	dir := c.hostDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".json")); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (c *Cache) invalidate() {
+	_ = os.RemoveAll(c.hostDir())
+}
+
+// decodePartial decodes the partial data that accompanied err and returns err,
+// so decorators pass partial GraphQL responses through unchanged.
+func decodePartial(data []byte, result interface{}, err error) error {
+	if decodeErr := decodeCached(data, result); decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+func decodeCached(data []byte, result interface{}) error {
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("unmarshal cached response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package ghcli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingStub answers every read with the number of upstream calls made so
+// far, so tests can tell cached responses from fresh ones.
+type countingStub struct {
+	calls   int
+	partial bool
+}
+
+func (s *countingStub) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	s.calls++
+	return decodeCached([]byte(`{"n": `+strconv.Itoa(s.calls)+`}`), result)
+}
+
+func (s *countingStub) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	s.calls++
+	if err := decodeCached([]byte(`{"n": `+strconv.Itoa(s.calls)+`}`), result); err != nil {
+		return err
+	}
+	if s.partial {
+		return &GraphQLError{Errors: []GraphQLErrorEntry{{Type: GraphQLForbidden, Message: "withheld"}}, Partial: true}
+	}
+	return nil
+}
+
+type counted struct {
+	N int `json:"n"`
+}
+
+const viewerQuery = "query Viewer { viewer { login } }"
+
+func newTestCache(t *testing.T, upstream API, identity, dir string) (*Cache, *time.Time) {
+	t.Helper()
+	cache := NewCache(upstream, "github.com", identity, dir, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func read(t *testing.T, cache *Cache) int {
+	t.Helper()
+	var result counted
+	if err := cache.GraphQL(viewerQuery, map[string]interface{}{"n": 1}, &result); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return result.N
+}
+
+func TestCacheServesFreshEntriesUntilTTL(t *testing.T) {
+	upstream := &countingStub{}
+	cache, now := newTestCache(t, upstream, "octocat", t.TempDir())
+
+	if n := read(t, cache); n != 1 {
+		t.Fatalf("first read = %d, want 1", n)
+	}
+	*now = now.Add(30 * time.Second)
+	if n := read(t, cache); n != 1 {
+		t.Fatalf("read within TTL = %d, want the cached 1", n)
+	}
+	*now = now.Add(31 * time.Second)
+	if n := read(t, cache); n != 2 {
+		t.Fatalf("read after TTL = %d, want a fresh 2", n)
+	}
+
+	var other counted
+	if err := cache.GraphQL(viewerQuery, map[string]interface{}{"n": 2}, &other); err != nil {
+		t.Fatal(err)
+	}
+	if other.N != 3 {
+		t.Fatalf("read with other variables = %d, want a fresh 3", other.N)
+	}
+}
+
+func TestCacheSeparatesIdentities(t *testing.T) {
+	dir := t.TempDir()
+	upstream := &countingStub{}
+	alice, _ := newTestCache(t, upstream, "alice", dir)
+	bob, _ := newTestCache(t, upstream, "bob", dir)
+
+	if n := read(t, alice); n != 1 {
+		t.Fatalf("alice = %d, want 1", n)
+	}
+	if n := read(t, bob); n != 2 {
+		t.Fatalf("bob = %d, want his own response, not alice's", n)
+	}
+	if n := read(t, alice); n != 1 {
+		t.Fatalf("alice again = %d, want her cached response", n)
+	}
+}
+
+func TestCacheInvalidatesOnWrites(t *testing.T) {
+	upstream := &countingStub{}
+	cache, _ := newTestCache(t, upstream, "octocat", t.TempDir())
+
+	read(t, cache)
+	if err := cache.GraphQL("mutation Resolve { resolveReviewThread { thread { id } } }", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := read(t, cache); n != 3 {
+		t.Fatalf("read after mutation = %d, want a fresh 3", n)
+	}
+
+	if err := cache.REST("POST", "repos/acme/widgets/issues/7/comments", nil, map[string]string{"body": "hi"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := read(t, cache); n != 5 {
+		t.Fatalf("read after REST POST = %d, want a fresh 5", n)
+	}
+}
+
+func TestCacheDoesNotStorePartialData(t *testing.T) {
+	upstream := &countingStub{partial: true}
+	cache, _ := newTestCache(t, upstream, "octocat", t.TempDir())
+
+	for want := 1; want <= 2; want++ {
+		var result counted
+		err := cache.GraphQL(viewerQuery, nil, &result)
+		if _, partial := PartialGraphQLError(err); !partial {
+			t.Fatalf("err = %v, want the partial error passed through", err)
+		}
+		if result.N != want {
+			t.Fatalf("read %d = %d, want fresh partial data", want, result.N)
+		}
+	}
+}
+
+func TestCacheStoresPlainRESTReads(t *testing.T) {
+	upstream := &countingStub{}
+	cache, _ := newTestCache(t, upstream, "octocat", t.TempDir())
+
+	for i := 0; i < 2; i++ {
+		var result counted
+		if err := cache.REST("GET", "repos/acme/widgets/pulls/7", nil, nil, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.N != 1 {
+			t.Fatalf("read %d = %d, want the cached 1", i, result.N)
+		}
+	}
+}
+
+func TestCachePassesUpstreamErrorsThrough(t *testing.T) {
+	failure := errors.New("boom")
+	cache, _ := newTestCache(t, stubAPI{graphql: func(string, map[string]interface{}, interface{}) error { return failure }}, "octocat", t.TempDir())
+	if err := cache.GraphQL(viewerQuery, nil, &counted{}); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want the upstream error", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(cache.Dir, "github.com"))
+	if len(entries) != 0 {
+		t.Fatalf("cached %d entries for a failed read", len(entries))
+	}
+}
+
+func TestCredentialIdentityHashesTheToken(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"auth token\" ] || exit 2\nprintf '%s\\n' \"$FAKE_GH_TOKEN\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	t.Setenv("FAKE_GH_TOKEN", "gho_alice")
+	alice, err := CredentialIdentity("github.com")
+	if err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+	if strings.Contains(alice, "gho_alice") {
+		t.Fatal("identity contains the token")
+	}
+	t.Setenv("FAKE_GH_TOKEN", "gho_bob")
+	bob, err := CredentialIdentity("github.com")
+	if err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+	if alice == bob {
+		t.Fatal("different tokens share an identity")
+	}
+
+	t.Setenv("FAKE_GH_TOKEN", "")
+	if _, err := CredentialIdentity("github.com"); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+}
@@ -0,0 +1,331 @@
+package ghcli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Exchange kinds stored in fixture files.
+const (
+	ExchangeGraphQL = "graphql"
+	ExchangeREST    = "rest"
+	ExchangeGh      = "gh"
+)
+
+// Exchange is one recorded request/response pair.
+type Exchange struct {
+	Kind      string            `json:"kind"`
+	Host      string            `json:"host,omitempty"`
+	Operation string            `json:"operation,omitempty"`
+	Query     string            `json:"query,omitempty"`
+	Variables json.RawMessage   `json:"variables,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Response  json.RawMessage   `json:"response,omitempty"`
+	Output    string            `json:"output,omitempty"`
+	Error     *FixtureError     `json:"error,omitempty"`
+}
+
+// FixtureError captures a failed exchange so replays fail the same way.
+type FixtureError struct {
+	Message    string              `json:"message"`
+	StatusCode int                 `json:"status_code,omitempty"`
+	GraphQL    []GraphQLErrorEntry `json:"graphql,omitempty"`
+	Partial    bool                `json:"partial,omitempty"`
+}
+
+// Fixture is the golden file layout.
+type Fixture struct {
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+var (
+	operationRE  = regexp.MustCompile(`^\s*(?:query|mutation)\s+(\w+)`)
+	whitespaceRE = regexp.MustCompile(`\s+`)
+)
+
+// OperationName returns the name of a GraphQL operation, or "" when anonymous.
+func OperationName(query string) string {
+	if m := operationRE.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func normalizeQuery(query string) string {
+	return strings.TrimSpace(whitespaceRE.ReplaceAllString(query, " "))
+}
+
+// exchangeKey identifies an exchange for replay matching. Queries are compared
+// with whitespace collapsed and variables/params by their canonical JSON.
+func exchangeKey(e Exchange) string {
+	var parts []string
+	switch e.Kind {
+	case ExchangeGraphQL:
+		parts = []string{e.Kind, e.Host, normalizeQuery(e.Query), canonicalJSON(e.Variables)}
+	case ExchangeREST:
+		params := ""
+		if len(e.Params) > 0 {
+			data, _ := json.Marshal(e.Params)
+			params = string(data)
+		}
+		parts = []string{e.Kind, e.Host, strings.ToUpper(e.Method), e.Path, params, canonicalJSON(e.Body)}
+	default:
+		parts = append([]string{e.Kind}, e.Args...)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func canonicalJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return string(raw)
+	}
+	return string(data)
+}
+
+func marshalRaw(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	if string(data) == "null" || string(data) == "{}" {
+		return nil
+	}
+	return data
+}
+
+func fixtureError(err error) *FixtureError {
+	if err == nil {
+		return nil
+	}
+	fe := &FixtureError{Message: err.Error()}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		fe.Message = apiErr.Message
+		fe.StatusCode = apiErr.StatusCode
+	}
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) {
+		fe.GraphQL = gqlErr.Errors
+		fe.Partial = gqlErr.Partial
+	}
+	return fe
+}
+
+func (fe *FixtureError) err() error {
+	if len(fe.GraphQL) > 0 {
+		return &GraphQLError{Errors: fe.GraphQL, Partial: fe.Partial}
+	}
+	return &APIError{StatusCode: fe.StatusCode, Message: fe.Message}
+}
+
+// Recorder captures every exchange made through the clients it wraps and
+// writes them to a fixture file after each call.
+type Recorder struct {
+	Path string
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder creates a recorder writing to path.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{Path: path}
+}
+
+// Wrap returns an API that forwards to api and records each exchange for host.
+func (r *Recorder) Wrap(api API, host string) API {
+	return &recordingAPI{recorder: r, api: api, host: host}
+}
+
+// WrapRunner records invocations of a `gh` runner such as the one used by the resolver.
+func (r *Recorder) WrapRunner(run func(args ...string) ([]byte, error)) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		output, err := run(args...)
+		r.add(Exchange{Kind: ExchangeGh, Args: args, Output: string(output), Error: fixtureError(err)})
+		return output, err
+	}
+}
+
+func (r *Recorder) add(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, e)
+	_ = r.save()
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(Fixture{Exchanges: r.exchanges}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(r.Path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write fixture: %w", err)
+	}
+	return nil
+}
+
+type recordingAPI struct {
+	recorder *Recorder
+	api      API
+	host     string
+}
+
+func (a *recordingAPI) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	var raw json.RawMessage
+	var target interface{}
+	if result != nil {
+		target = &raw
+	}
+	err := a.api.REST(method, path, params, body, target)
+	a.recorder.add(Exchange{
+		Kind:     ExchangeREST,
+		Host:     a.host,
+		Method:   method,
+		Path:     path,
+		Params:   params,
+		Body:     marshalRaw(body),
+		Response: raw,
+		Error:    fixtureError(err),
+	})
+	if err != nil {
+		return err
+	}
+	return decodeCached(raw, result)
+}
+
+func (a *recordingAPI) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	var raw json.RawMessage
+	err := a.api.GraphQL(query, variables, &raw)
+	a.recorder.add(Exchange{
+		Kind:      ExchangeGraphQL,
+		Host:      a.host,
+		Operation: OperationName(query),
+		Query:     query,
+		Variables: marshalRaw(variables),
+		Response:  raw,
+		Error:     fixtureError(err),
+	})
+	if _, partial := PartialGraphQLError(err); partial {
+		return decodePartial(raw, result, err)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeCached(raw, result)
+}
+
+// Replayer serves exchanges from a fixture file. Identical requests are answered
+// in recorded order; once exhausted, the last recorded answer is repeated.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges map[string][]Exchange
+}
+
+// LoadReplayer reads a fixture file written by Recorder.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return NewReplayer(fixture), nil
+}
+
+// NewReplayer builds a replayer from an in-memory fixture.
+func NewReplayer(fixture Fixture) *Replayer {
+	r := &Replayer{exchanges: make(map[string][]Exchange)}
+	for _, e := range fixture.Exchanges {
+		key := exchangeKey(e)
+		r.exchanges[key] = append(r.exchanges[key], e)
+	}
+	return r
+}
+
+// Client returns an API that answers requests for host from the fixture.
+func (r *Replayer) Client(host string) API {
+	return &replayAPI{replayer: r, host: host}
+}
+
+// RunGh answers `gh` invocations (e.g. `gh pr view`) from the fixture.
+func (r *Replayer) RunGh(args ...string) ([]byte, error) {
+	e, err := r.next(Exchange{Kind: ExchangeGh, Args: args}, "gh "+strings.Join(args, " "))
+	if err != nil {
+		return nil, err
+	}
+	if e.Error != nil {
+		return nil, errors.New(e.Error.Message)
+	}
+	return []byte(e.Output), nil
+}
+
+func (r *Replayer) next(request Exchange, label string) (Exchange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := exchangeKey(request)
+	queue := r.exchanges[key]
+	if len(queue) == 0 {
+		return Exchange{}, fmt.Errorf("no recorded exchange for %s", label)
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		r.exchanges[key] = queue[1:]
+	}
+	return e, nil
+}
+
+type replayAPI struct {
+	replayer *Replayer
+	host     string
+}
+
+func (a *replayAPI) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	request := Exchange{Kind: ExchangeREST, Host: a.host, Method: method, Path: path, Params: params, Body: marshalRaw(body)}
+	e, err := a.replayer.next(request, fmt.Sprintf("%s %s", method, path))
+	if err != nil {
+		return err
+	}
+	if e.Error != nil {
+		return e.Error.err()
+	}
+	return decodeCached(e.Response, result)
+}
+
+func (a *replayAPI) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	request := Exchange{Kind: ExchangeGraphQL, Host: a.host, Query: query, Variables: marshalRaw(variables)}
+	e, err := a.replayer.next(request, "graphql operation "+OperationName(query))
+	if err != nil {
+		return err
+	}
+	if e.Error != nil {
+		err := e.Error.err()
+		if _, partial := PartialGraphQLError(err); partial {
+			return decodePartial(e.Response, result, err)
+		}
+		return err
+	}
+	return decodeCached(e.Response, result)
+}
@@ -0,0 +1,164 @@
+package ghcli
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const listQuery = `query ListThreads($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { id }
+}`
+
+func TestReplayMatchesNormalizedQueryAndVariables(t *testing.T) {
+	replayer := NewReplayer(Fixture{Exchanges: []Exchange{{
+		Kind:      ExchangeGraphQL,
+		Host:      "github.com",
+		Query:     "query ListThreads($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { id } }",
+		Variables: json.RawMessage(`{"name": "widgets", "owner": "acme"}`),
+		Response:  json.RawMessage(`{"repository": {"id": "R_1"}}`),
+	}}})
+
+	var result struct {
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+	variables := map[string]interface{}{"owner": "acme", "name": "widgets"}
+	if err := replayer.Client("github.com").GraphQL(listQuery, variables, &result); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if result.Repository.ID != "R_1" {
+		t.Fatalf("repository id = %q, want R_1", result.Repository.ID)
+	}
+}
+
+func TestReplayRejectsDifferentVariables(t *testing.T) {
+	replayer := NewReplayer(Fixture{Exchanges: []Exchange{{
+		Kind:      ExchangeGraphQL,
+		Host:      "github.com",
+		Query:     listQuery,
+		Variables: json.RawMessage(`{"owner": "acme", "name": "widgets"}`),
+		Response:  json.RawMessage(`{}`),
+	}}})
+
+	variables := map[string]interface{}{"owner": "acme", "name": "gadgets"}
+	err := replayer.Client("github.com").GraphQL(listQuery, variables, nil)
+	if err == nil {
+		t.Fatal("expected an error for unrecorded variables")
+	}
+}
+
+func TestReplayServesRepeatedRequestsInOrder(t *testing.T) {
+	exchange := func(state string) Exchange {
+		return Exchange{
+			Kind:     ExchangeREST,
+			Host:     "github.com",
+			Method:   "GET",
+			Path:     "repos/acme/widgets/pulls/7",
+			Response: json.RawMessage(`{"state": "` + state + `"}`),
+		}
+	}
+	replayer := NewReplayer(Fixture{Exchanges: []Exchange{exchange("open"), exchange("closed")}})
+	client := replayer.Client("github.com")
+
+	for _, want := range []string{"open", "closed", "closed"} {
+		var result struct {
+			State string `json:"state"`
+		}
+		if err := client.REST("get", "repos/acme/widgets/pulls/7", nil, nil, &result); err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		if result.State != want {
+			t.Fatalf("state = %q, want %q", result.State, want)
+		}
+	}
+}
+
+func TestReplayReturnsPartialData(t *testing.T) {
+	replayer := NewReplayer(Fixture{Exchanges: []Exchange{{
+		Kind:     ExchangeGraphQL,
+		Host:     "github.com",
+		Query:    listQuery,
+		Response: json.RawMessage(`{"repository": {"id": "R_1"}}`),
+		Error: &FixtureError{
+			Message: "forbidden",
+			Partial: true,
+			GraphQL: []GraphQLErrorEntry{{Type: GraphQLForbidden, Message: "forbidden", Path: []interface{}{"repository", "owner"}}},
+		},
+	}}})
+
+	var result struct {
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+	err := replayer.Client("github.com").GraphQL(listQuery, nil, &result)
+	if _, partial := PartialGraphQLError(err); !partial {
+		t.Fatalf("expected a partial GraphQL error, got %v", err)
+	}
+	if !IsGraphQLErrorType(err, GraphQLForbidden) {
+		t.Fatalf("expected a FORBIDDEN error, got %v", err)
+	}
+	if result.Repository.ID != "R_1" {
+		t.Fatalf("partial data was not decoded: %+v", result)
+	}
+}
+
+func TestReplayGhInvocation(t *testing.T) {
+	replayer := NewReplayer(Fixture{Exchanges: []Exchange{
+		{Kind: ExchangeGh, Args: []string{"pr", "view", "7"}, Output: `{"number": 7}`},
+		{Kind: ExchangeGh, Args: []string{"pr", "view", "8"}, Error: &FixtureError{Message: "no pull requests found"}},
+	}})
+
+	output, err := replayer.RunGh("pr", "view", "7")
+	if err != nil || string(output) != `{"number": 7}` {
+		t.Fatalf("RunGh = %q, %v", output, err)
+	}
+	if _, err := replayer.RunGh("pr", "view", "8"); err == nil || err.Error() != "no pull requests found" {
+		t.Fatalf("expected recorded error, got %v", err)
+	}
+	if _, err := replayer.RunGh("pr", "view", "9"); err == nil {
+		t.Fatal("expected an error for an unrecorded invocation")
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := t.TempDir() + "/fixture.json"
+	recorder := NewRecorder(path)
+	upstream := stubAPI{graphql: func(query string, variables map[string]interface{}, result interface{}) error {
+		return json.Unmarshal([]byte(`{"viewer": {"login": "octocat"}}`), result)
+	}}
+	query := "query Viewer { viewer { login } }"
+	if err := recorder.Wrap(upstream, "github.com").GraphQL(query, nil, &json.RawMessage{}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	replayer, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var result struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	if err := replayer.Client("github.com").GraphQL("query   Viewer {\n  viewer { login }\n}", nil, &result); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if result.Viewer.Login != "octocat" {
+		t.Fatalf("login = %q, want octocat", result.Viewer.Login)
+	}
+}
+
+type stubAPI struct {
+	graphql func(query string, variables map[string]interface{}, result interface{}) error
+}
+
+func (s stubAPI) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	return errors.New("unexpected REST call")
+}
+
+func (s stubAPI) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	return s.graphql(query, variables, result)
+}
@@ -1,19 +1,27 @@
 package ghcli
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Client executes GitHub API requests through the `gh` CLI to reuse
 // the authenticated context and host configuration provided by the user.
 type Client struct {
 	Host string
+	// Tracer, when set, receives an event for every `gh api` invocation.
+	Tracer Tracer
 }
 
 // API defines the subset of GitHub API interactions required by the command logic.
@@ -22,15 +30,87 @@ type API interface {
 	GraphQL(query string, variables map[string]interface{}, result interface{}) error
 }
 
+// GraphQL error types GitHub reports in an error's `type` field.
+const (
+	GraphQLNotFound    = "NOT_FOUND"
+	GraphQLForbidden   = "FORBIDDEN"
+	GraphQLRateLimited = "RATE_LIMITED"
+)
+
 // GraphQLErrorEntry captures a single GraphQL error payload.
 type GraphQLErrorEntry struct {
-	Message string        `json:"message"`
-	Path    []interface{} `json:"path,omitempty"`
+	Message    string                 `json:"message"`
+	Type       string                 `json:"type,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation points at the part of the query an error refers to.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Kind returns the error's type, falling back to the `code` extension used by
+// some GraphQL servers.
+func (e GraphQLErrorEntry) Kind() string {
+	if e.Type != "" {
+		return e.Type
+	}
+	if code, ok := e.Extensions["code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+// PathString renders the error path in dotted form, e.g.
+// "repository.pullRequest.reviewThreads.nodes.0".
+func (e GraphQLErrorEntry) PathString() string {
+	parts := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		switch v := p.(type) {
+		case float64:
+			parts = append(parts, strconv.Itoa(int(v)))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ".")
 }
 
 // GraphQLError represents GraphQL-level errors returned alongside a response.
+// When Partial is set, the response also carried data and it has been decoded
+// into the caller's result, so the caller may carry on with what was returned.
 type GraphQLError struct {
-	Errors []GraphQLErrorEntry
+	Errors  []GraphQLErrorEntry
+	Partial bool
+}
+
+// HasType reports whether any of the errors is of the given type.
+func (e *GraphQLError) HasType(kind string) bool {
+	for _, entry := range e.Errors {
+		if entry.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGraphQLErrorType reports whether err is a GraphQLError with an error of the given type.
+func IsGraphQLErrorType(err error, kind string) bool {
+	var gqlErr *GraphQLError
+	return errors.As(err, &gqlErr) && gqlErr.HasType(kind)
+}
+
+// PartialGraphQLError returns err as a GraphQLError when partial data was
+// decoded alongside it.
+func PartialGraphQLError(err error) (*GraphQLError, bool) {
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) && gqlErr.Partial {
+		return gqlErr, true
+	}
+	return nil, false
 }
 
 func (e *GraphQLError) Error() string {
@@ -111,17 +191,7 @@ func wrapError(err error, stdout []byte, stderr string) error {
 // REST invokes the REST API using `gh api`.
 // The result parameter must be a pointer and will be unmarshaled from JSON.
 func (c *Client) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
-	args := []string{"api"}
-	if host := strings.TrimSpace(c.Host); host != "" {
-		args = append(args, "--hostname", host)
-	}
-
-	args = append(args, "--header", "X-GitHub-Api-Version: 2022-11-28")
-	args = append(args, path, "-X", method)
-
-	for key, value := range params {
-		args = append(args, "-f", fmt.Sprintf("%s=%s", key, value))
-	}
+	args := c.restArgs(method, path, params)
 
 	var stdinData []byte
 	if body != nil {
@@ -133,22 +203,80 @@ func (c *Client) REST(method, path string, params map[string]string, body interf
 		args = append(args, "--input", "-")
 	}
 
-	stdout, stderr, err := runGh(args, stdinData)
+	event := TraceEvent{Kind: ExchangeREST, Method: method, Path: path}
+	resp, err := c.run(event, args, stdinData, false, strings.EqualFold(method, http.MethodGet))
 	if err != nil {
-		return wrapError(err, stdout, stderr)
+		return wrapError(err, resp.Body, resp.Stderr)
 	}
 
 	if result == nil {
 		return nil
 	}
 
-	if err := json.Unmarshal(stdout, result); err != nil {
+	if err := json.Unmarshal(resp.Body, result); err != nil {
 		return fmt.Errorf("unmarshal response: %w", err)
 	}
 
 	return nil
 }
 
+// RESTConditional performs a body-less REST request with `If-None-Match` set to
+// etag (when non-empty). It returns the raw response body and the response ETag;
+// notModified is true when GitHub answered 304 and body is empty.
+func (c *Client) RESTConditional(method, path string, params map[string]string, etag string) (body []byte, newETag string, notModified bool, err error) {
+	args := c.restArgs(method, path, params)
+	if etag != "" {
+		args = append(args, "--header", "If-None-Match: "+etag)
+	}
+
+	event := TraceEvent{Kind: ExchangeREST, Method: method, Path: path}
+	resp, runErr := c.run(event, args, nil, true, strings.EqualFold(method, http.MethodGet))
+	if resp.Status == http.StatusNotModified {
+		return nil, etag, true, nil
+	}
+	if runErr != nil {
+		return nil, "", false, wrapError(runErr, resp.Body, resp.Stderr)
+	}
+	return resp.Body, resp.Header.Get("ETag"), false, nil
+}
+
+func (c *Client) restArgs(method, path string, params map[string]string) []string {
+	args := []string{"api"}
+	if host := strings.TrimSpace(c.Host); host != "" {
+		args = append(args, "--hostname", host)
+	}
+
+	args = append(args, "--header", "X-GitHub-Api-Version: 2022-11-28")
+	args = append(args, path, "-X", method)
+
+	for key, value := range params {
+		args = append(args, "-f", fmt.Sprintf("%s=%s", key, value))
+	}
+	return args
+}
+
+// splitIncludedResponse parses `gh api --include` output into the status code,
+// headers and body. Output without a status line is returned as body only.
+func splitIncludedResponse(output []byte) (int, http.Header, []byte) {
+	reader := bufio.NewReader(bytes.NewReader(output))
+	statusLine, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(statusLine, "HTTP/") {
+		return 0, http.Header{}, output
+	}
+
+	status := 0
+	if fields := strings.Fields(statusLine); len(fields) >= 2 {
+		status, _ = strconv.Atoi(fields[1])
+	}
+
+	mime, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil && len(mime) == 0 {
+		mime = textproto.MIMEHeader{}
+	}
+	rest, _ := io.ReadAll(reader)
+	return status, http.Header(mime), rest
+}
+
 // GraphQL issues a GraphQL operation through `gh api graphql`.
 func (c *Client) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
 	payload := map[string]interface{}{
@@ -169,52 +297,149 @@ func (c *Client) GraphQL(query string, variables map[string]interface{}, result
 	}
 	args = append(args, "--input", "-")
 
-	stdout, stderr, err := runGh(args, data)
-	if err != nil {
-		return wrapError(err, stdout, stderr)
-	}
-
-	if result == nil {
-		return nil
-	}
+	event := TraceEvent{Kind: ExchangeGraphQL, Operation: OperationName(query), Variables: variables}
+	read := !strings.HasPrefix(strings.TrimSpace(query), "mutation")
+	resp, err := c.run(event, args, data, false, read)
+	stdout := resp.Body
 
 	var envelope struct {
 		Data   json.RawMessage   `json:"data"`
 		Errors []json.RawMessage `json:"errors"`
 	}
-	if err := json.Unmarshal(stdout, &envelope); err != nil {
+	if err != nil {
+		// gh exits non-zero when the response carries GraphQL errors, but the
+		// body still holds the errors and any partial data.
+		if json.Unmarshal(stdout, &envelope) != nil || len(envelope.Errors) == 0 {
+			return wrapError(err, stdout, resp.Stderr)
+		}
+	} else if result == nil {
+		return nil
+	} else if err := json.Unmarshal(stdout, &envelope); err != nil {
 		return fmt.Errorf("unmarshal graphql response: %w", err)
 	}
+
 	if len(envelope.Errors) > 0 {
-		errs := make([]GraphQLErrorEntry, 0, len(envelope.Errors))
+		gqlErr := &GraphQLError{Errors: make([]GraphQLErrorEntry, 0, len(envelope.Errors))}
 		for _, raw := range envelope.Errors {
 			var entry GraphQLErrorEntry
 			if err := json.Unmarshal(raw, &entry); err != nil {
 				entry.Message = strings.TrimSpace(string(raw))
 			}
-			errs = append(errs, entry)
+			gqlErr.Errors = append(gqlErr.Errors, entry)
 		}
-		return &GraphQLError{Errors: errs}
+		if result != nil && len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+			if err := json.Unmarshal(envelope.Data, result); err != nil {
+				return fmt.Errorf("unmarshal graphql data: %w", err)
+			}
+			gqlErr.Partial = true
+		}
+		return gqlErr
 	}
 
-	if len(envelope.Data) > 0 && result != nil {
+	if len(envelope.Data) > 0 {
 		if err := json.Unmarshal(envelope.Data, result); err != nil {
 			return fmt.Errorf("unmarshal graphql data: %w", err)
 		}
+		return nil
 	}
+	return json.Unmarshal(stdout, result)
+}
+
+// response is the outcome of a single `gh api` invocation. Status and Header
+// are only populated when the call was made with --include.
+type response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Stderr string
+}
 
-	if len(envelope.Data) == 0 && result != nil {
-		return json.Unmarshal(stdout, result)
+// maxAttempts bounds how often a read is sent when GitHub answers with a
+// transient server error.
+const maxAttempts = 3
+
+// retryDelay is the pause before the given retry; tests shorten it.
+var retryDelay = func(attempt int) time.Duration {
+	return time.Duration(attempt) * time.Second
+}
+
+// run invokes `gh` for the client. Response headers are requested when the
+// caller needs them or a Tracer is configured, so the trace can report the
+// status code and rate-limit usage. Reads (REST GETs and GraphQL queries) that
+// fail with 502, 503 or 504 are retried; each attempt is traced separately.
+func (c *Client) run(event TraceEvent, args []string, stdin []byte, include, read bool) (response, error) {
+	include = include || c.Tracer != nil
+	if include {
+		args = append(args, "--include")
 	}
 
-	return nil
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		stdout, stderr, err := runGh(args, stdin)
+		resp := response{Header: http.Header{}, Body: stdout, Stderr: stderr}
+		if include {
+			resp.Status, resp.Header, resp.Body = splitIncludedResponse(stdout)
+		}
+
+		if c.Tracer != nil {
+			traced := event
+			traced.Time = start
+			traced.Duration = time.Since(start)
+			traced.Host = c.Host
+			traced.Args = RedactArgs(args)
+			traced.Attempt = attempt
+			traced.Status = resp.Status
+			traced.RateLimit = rateLimitFromHeaders(resp.Header)
+			if event.Kind == ExchangeGraphQL {
+				traced.Cost = graphQLCost(resp.Body)
+			}
+			if err != nil {
+				traced.Error = strings.TrimSpace(stderr)
+				if traced.Error == "" {
+					traced.Error = err.Error()
+				}
+			}
+			c.Tracer.Trace(traced)
+		}
+
+		if err == nil || attempt >= maxAttempts || !read || !isTransient(resp.Status, stderr) {
+			return resp, err
+		}
+		time.Sleep(retryDelay(attempt))
+	}
+}
+
+func isTransient(status int, stderr string) bool {
+	if status == 0 {
+		if matches := statusRE.FindStringSubmatch(stderr); len(matches) == 2 {
+			status, _ = strconv.Atoi(matches[1])
+		}
+	}
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// graphQLCost returns the `rateLimit.cost` a query selected, or 0.
+func graphQLCost(body []byte) int {
+	var envelope struct {
+		Data struct {
+			RateLimit *struct {
+				Cost int `json:"cost"`
+			} `json:"rateLimit"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Data.RateLimit == nil {
+		return 0
+	}
+	return envelope.Data.RateLimit.Cost
 }
 
 // runGh executes the `gh` CLI command with provided arguments and optional stdin data.
 func runGh(args []string, stdin []byte) ([]byte, string, error) {
 	cmd := exec.Command("gh", args...)
-	// DEBUG LOG
-	// fmt.Fprintf(os.Stderr, "running gh %s\n", strings.Join(args, " "))
 	if stdin != nil {
 		cmd.Stdin = bytes.NewReader(stdin)
 	}
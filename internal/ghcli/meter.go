@@ -0,0 +1,155 @@
+package ghcli
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Usage summarizes the API calls made by one command invocation.
+type Usage struct {
+	Calls     int                      `json:"calls"`
+	Cost      int                      `json:"cost"`
+	ElapsedMS int64                    `json:"elapsed_ms"`
+	RateLimit map[string]ResourceQuota `json:"rate_limit,omitempty"`
+}
+
+// ResourceQuota is the most recent quota GitHub reported for a rate-limit resource.
+type ResourceQuota struct {
+	Remaining int    `json:"remaining"`
+	ResetAt   string `json:"reset_at,omitempty"`
+}
+
+// Meter is an API decorator that counts calls and accumulates their cost.
+//
+// GraphQL cost and remaining quota come from the `rateLimit` field selected by
+// the query; REST calls cost one request each and report the quota through the
+// response headers, which the meter receives as a Tracer of the underlying Client.
+type Meter struct {
+	mu     sync.Mutex
+	start  time.Time
+	calls  int
+	cost   int
+	quotas map[string]ResourceQuota
+}
+
+// NewMeter starts measuring from now.
+func NewMeter() *Meter {
+	return &Meter{start: time.Now(), quotas: make(map[string]ResourceQuota)}
+}
+
+// Wrap returns an API that forwards to api and records each call. When api
+// supports ETag revalidation, so does the returned API, keeping a Cache in
+// front of the meter able to revalidate.
+func (m *Meter) Wrap(api API) API {
+	metered := &meteredAPI{meter: m, api: api}
+	if conditional, ok := api.(ConditionalREST); ok {
+		return &meteredConditionalAPI{meteredAPI: metered, conditional: conditional}
+	}
+	return metered
+}
+
+// Trace implements Tracer, picking up REST quota from response headers.
+func (m *Meter) Trace(event TraceEvent) {
+	if event.Kind != ExchangeREST || event.RateLimit == nil {
+		return
+	}
+	resource := event.RateLimit.Resource
+	if resource == "" {
+		resource = "core"
+	}
+	quota := ResourceQuota{Remaining: event.RateLimit.Remaining}
+	if event.RateLimit.Reset > 0 {
+		quota.ResetAt = time.Unix(event.RateLimit.Reset, 0).UTC().Format(time.RFC3339)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotas[resource] = quota
+}
+
+// Usage returns the totals recorded so far.
+func (m *Meter) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := Usage{
+		Calls:     m.calls,
+		Cost:      m.cost,
+		ElapsedMS: time.Since(m.start).Milliseconds(),
+	}
+	if len(m.quotas) > 0 {
+		usage.RateLimit = make(map[string]ResourceQuota, len(m.quotas))
+		for resource, quota := range m.quotas {
+			usage.RateLimit[resource] = quota
+		}
+	}
+	return usage
+}
+
+func (m *Meter) record(cost int, resource string, quota *ResourceQuota) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	m.cost += cost
+	if quota != nil {
+		m.quotas[resource] = *quota
+	}
+}
+
+type meteredAPI struct {
+	meter *Meter
+	api   API
+}
+
+func (a *meteredAPI) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	err := a.api.REST(method, path, params, body, result)
+	a.meter.record(1, "", nil)
+	return err
+}
+
+func (a *meteredAPI) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	var raw json.RawMessage
+	err := a.api.GraphQL(query, variables, &raw)
+
+	var envelope struct {
+		RateLimit *struct {
+			Cost      int    `json:"cost"`
+			Remaining int    `json:"remaining"`
+			ResetAt   string `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &envelope)
+	}
+	if rl := envelope.RateLimit; rl != nil {
+		a.meter.record(rl.Cost, "graphql", &ResourceQuota{Remaining: rl.Remaining, ResetAt: rl.ResetAt})
+	} else {
+		a.meter.record(0, "", nil)
+	}
+
+	if _, partial := PartialGraphQLError(err); partial {
+		return decodePartial(raw, result, err)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeCached(raw, result)
+}
+
+type meteredConditionalAPI struct {
+	*meteredAPI
+	conditional ConditionalREST
+}
+
+// RESTConditional forwards the revalidation. GitHub does not charge quota for a
+// 304 Not Modified answer, so those count as a call without cost.
+func (a *meteredConditionalAPI) RESTConditional(method, path string, params map[string]string, etag string) ([]byte, string, bool, error) {
+	body, newETag, notModified, err := a.conditional.RESTConditional(method, path, params, etag)
+	cost := 1
+	if notModified {
+		cost = 0
+	}
+	a.meter.record(cost, "", nil)
+	return body, newETag, notModified, err
+}
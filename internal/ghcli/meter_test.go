@@ -0,0 +1,77 @@
+package ghcli
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// conditionalStub answers REST GETs with a fixed ETag and 304s when it is sent back.
+type conditionalStub struct {
+	requests     int
+	conditionals int
+}
+
+func (s *conditionalStub) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	s.requests++
+	return decodeCached([]byte(`{"state": "open"}`), result)
+}
+
+func (s *conditionalStub) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	return errors.New("unexpected GraphQL call")
+}
+
+func (s *conditionalStub) RESTConditional(method, path string, params map[string]string, etag string) ([]byte, string, bool, error) {
+	s.conditionals++
+	if etag == `"v1"` {
+		return nil, etag, true, nil
+	}
+	return []byte(`{"state": "open"}`), `"v1"`, false, nil
+}
+
+func TestCacheRevalidatesThroughMeter(t *testing.T) {
+	upstream := &conditionalStub{}
+	meter := NewMeter()
+	cache := NewCache(meter.Wrap(upstream), "github.com", "octocat", t.TempDir(), time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	var result struct {
+		State string `json:"state"`
+	}
+	if err := cache.REST("GET", "repos/acme/widgets/pulls/7", nil, nil, &result); err != nil {
+		t.Fatalf("first read: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := cache.REST("GET", "repos/acme/widgets/pulls/7", nil, nil, &result); err != nil {
+		t.Fatalf("revalidated read: %v", err)
+	}
+
+	if upstream.conditionals != 2 || upstream.requests != 0 {
+		t.Fatalf("conditional=%d plain=%d, want every read revalidated", upstream.conditionals, upstream.requests)
+	}
+	if result.State != "open" {
+		t.Fatalf("state = %q, want open", result.State)
+	}
+	usage := meter.Usage()
+	if usage.Calls != 2 || usage.Cost != 1 {
+		t.Fatalf("usage = %+v, want 2 calls costing 1 (the 304 is free)", usage)
+	}
+}
+
+func TestMeterWrapKeepsPlainAPIs(t *testing.T) {
+	api := NewMeter().Wrap(stubAPI{})
+	if _, ok := api.(ConditionalREST); ok {
+		t.Fatal("wrapped API claims ETag support its client lacks")
+	}
+}
+
+func TestMultiTracerWithoutTracersIsNil(t *testing.T) {
+	if tracer := MultiTracer(nil, nil); tracer != nil {
+		t.Fatalf("MultiTracer(nil, nil) = %#v, want nil", tracer)
+	}
+	meter := NewMeter()
+	if tracer := MultiTracer(nil, meter); tracer != Tracer(meter) {
+		t.Fatalf("MultiTracer(nil, meter) = %#v, want the meter", tracer)
+	}
+}
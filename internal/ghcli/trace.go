@@ -0,0 +1,180 @@
+package ghcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit captures the rate-limit headers GitHub returns with every response.
+type RateLimit struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	Used      int    `json:"used"`
+	Resource  string `json:"resource,omitempty"`
+	Reset     int64  `json:"reset,omitempty"`
+}
+
+// TraceEvent describes one `gh` invocation made by the client.
+type TraceEvent struct {
+	Time      time.Time              `json:"time"`
+	Kind      string                 `json:"kind"`
+	Host      string                 `json:"host,omitempty"`
+	Operation string                 `json:"operation,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Path      string                 `json:"path,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Args      []string               `json:"args"`
+	Attempt   int                    `json:"attempt,omitempty"`
+	Status    int                    `json:"status,omitempty"`
+	Duration  time.Duration          `json:"duration_ns"`
+	Cost      int                    `json:"cost,omitempty"`
+	RateLimit *RateLimit             `json:"rate_limit,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// Tracer receives an event after every API call.
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+// LogTracer writes a one-line summary of each event to Log and, when File is
+// set, the full event as NDJSON for machine consumption.
+type LogTracer struct {
+	Log  io.Writer
+	File io.Writer
+
+	mu sync.Mutex
+}
+
+// Trace implements Tracer.
+func (t *LogTracer) Trace(event TraceEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Log != nil {
+		_, _ = fmt.Fprintln(t.Log, formatTraceEvent(event))
+	}
+	if t.File != nil {
+		if data, err := json.Marshal(event); err == nil {
+			_, _ = t.File.Write(append(data, '\n'))
+		}
+	}
+}
+
+// TraceRunner reports invocations of a `gh` runner such as the one used by the resolver.
+func TraceRunner(tracer Tracer, run func(args ...string) ([]byte, error)) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		start := time.Now()
+		output, err := run(args...)
+		event := TraceEvent{Time: start, Kind: ExchangeGh, Args: RedactArgs(args), Attempt: 1, Duration: time.Since(start)}
+		if err != nil {
+			event.Error = err.Error()
+		}
+		tracer.Trace(event)
+		return output, err
+	}
+}
+
+func formatTraceEvent(e TraceEvent) string {
+	var b strings.Builder
+	b.WriteString("[gh-pr-comments] ")
+	switch e.Kind {
+	case ExchangeGraphQL:
+		fmt.Fprintf(&b, "graphql %s", e.Operation)
+	case ExchangeREST:
+		fmt.Fprintf(&b, "rest %s %s", e.Method, e.Path)
+	default:
+		fmt.Fprintf(&b, "gh %s", strings.Join(e.Args, " "))
+	}
+	if e.Host != "" {
+		fmt.Fprintf(&b, " host=%s", e.Host)
+	}
+	if len(e.Variables) > 0 {
+		if data, err := json.Marshal(e.Variables); err == nil {
+			fmt.Fprintf(&b, " variables=%s", data)
+		}
+	}
+	if e.Attempt > 1 {
+		fmt.Fprintf(&b, " attempt=%d", e.Attempt)
+	}
+	if e.Status > 0 {
+		fmt.Fprintf(&b, " status=%d", e.Status)
+	}
+	fmt.Fprintf(&b, " duration=%s", e.Duration.Round(time.Millisecond))
+	if e.Cost > 0 {
+		fmt.Fprintf(&b, " cost=%d", e.Cost)
+	}
+	if e.RateLimit != nil {
+		fmt.Fprintf(&b, " ratelimit=%d/%d", e.RateLimit.Remaining, e.RateLimit.Limit)
+		if e.RateLimit.Resource != "" {
+			fmt.Fprintf(&b, " resource=%s", e.RateLimit.Resource)
+		}
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&b, " error=%q", e.Error)
+	}
+	return b.String()
+}
+
+var sensitiveHeaderRE = regexp.MustCompile(`(?i)^\s*(authorization|cookie|x-github-token|proxy-authorization)\s*:`)
+
+// RedactArgs returns a copy of gh arguments with credential-bearing headers masked.
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i+1 < len(redacted); i++ {
+		if redacted[i] != "--header" && redacted[i] != "-H" {
+			continue
+		}
+		if m := sensitiveHeaderRE.FindStringSubmatch(redacted[i+1]); m != nil {
+			redacted[i+1] = m[1] + ": REDACTED"
+		}
+	}
+	return redacted
+}
+
+func rateLimitFromHeaders(headers http.Header) *RateLimit {
+	limit := headers.Get("X-Ratelimit-Limit")
+	if limit == "" {
+		return nil
+	}
+	rl := &RateLimit{Resource: headers.Get("X-Ratelimit-Resource")}
+	rl.Limit, _ = strconv.Atoi(limit)
+	rl.Remaining, _ = strconv.Atoi(headers.Get("X-Ratelimit-Remaining"))
+	rl.Used, _ = strconv.Atoi(headers.Get("X-Ratelimit-Used"))
+	rl.Reset, _ = strconv.ParseInt(headers.Get("X-Ratelimit-Reset"), 10, 64)
+	return rl
+}
+
+// MultiTracer fans events out to every non-nil tracer. It returns nil when
+// none is set, so clients skip tracing altogether.
+func MultiTracer(tracers ...Tracer) Tracer {
+	active := make(multiTracer, 0, len(tracers))
+	for _, t := range tracers {
+		if t != nil {
+			active = append(active, t)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return active
+}
+
+type multiTracer []Tracer
+
+func (m multiTracer) Trace(event TraceEvent) {
+	for _, t := range m {
+		t.Trace(event)
+	}
+}
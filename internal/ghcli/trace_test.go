@@ -0,0 +1,92 @@
+package ghcli
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type recordingTracer struct {
+	events []TraceEvent
+}
+
+func (r *recordingTracer) Trace(event TraceEvent) {
+	r.events = append(r.events, event)
+}
+
+// fakeGh puts a `gh` script on PATH that fails with HTTP 502 for the first
+// failures calls and then prints response.
+func fakeGh(t *testing.T, failures int, response string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+count=$(cat "$0.count" 2>/dev/null || echo 0)
+count=$((count + 1))
+echo "$count" > "$0.count"
+cat > /dev/null
+if [ "$count" -le ` + strconv.Itoa(failures) + ` ]; then
+  printf 'HTTP/2.0 502 Bad Gateway\r\n\r\n'
+  echo "gh: Bad Gateway (HTTP 502)" >&2
+  exit 1
+fi
+printf 'HTTP/2.0 200 OK\r\nX-Ratelimit-Limit: 5000\r\nX-Ratelimit-Remaining: 4990\r\nX-Ratelimit-Resource: graphql\r\n\r\n'
+cat <<'EOF'
+` + response + `
+EOF
+`
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	delay := retryDelay
+	retryDelay = func(int) time.Duration { return 0 }
+	t.Cleanup(func() { retryDelay = delay })
+}
+
+func TestTraceReportsCostAndRetriedAttempts(t *testing.T) {
+	fakeGh(t, 1, `{"data": {"viewer": {"login": "octocat"}, "rateLimit": {"cost": 2, "remaining": 4990}}}`)
+	tracer := &recordingTracer{}
+	client := &Client{Tracer: tracer}
+
+	var result struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	if err := client.GraphQL("query Viewer { viewer { login } rateLimit { cost remaining } }", nil, &result); err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	if result.Viewer.Login != "octocat" {
+		t.Fatalf("login = %q, want octocat", result.Viewer.Login)
+	}
+
+	if len(tracer.events) != 2 {
+		t.Fatalf("traced %d events, want 2", len(tracer.events))
+	}
+	failed, succeeded := tracer.events[0], tracer.events[1]
+	if failed.Attempt != 1 || failed.Status != 502 || failed.Error == "" {
+		t.Fatalf("unexpected first attempt: %+v", failed)
+	}
+	if succeeded.Attempt != 2 || succeeded.Status != 200 || succeeded.Cost != 2 {
+		t.Fatalf("unexpected second attempt: %+v", succeeded)
+	}
+	if succeeded.RateLimit == nil || succeeded.RateLimit.Remaining != 4990 {
+		t.Fatalf("rate limit not reported: %+v", succeeded.RateLimit)
+	}
+}
+
+func TestMutationsAreNotRetried(t *testing.T) {
+	fakeGh(t, 1, `{"data": {}}`)
+	tracer := &recordingTracer{}
+	client := &Client{Tracer: tracer}
+
+	if err := client.GraphQL("mutation Resolve { resolveReviewThread(input: {}) { clientMutationId } }", nil, nil); err == nil {
+		t.Fatal("expected the 502 to be returned")
+	}
+	if len(tracer.events) != 1 || tracer.events[0].Attempt != 1 {
+		t.Fatalf("mutation was retried: %+v", tracer.events)
+	}
+}
@@ -0,0 +1,568 @@
+// Package ghfake provides an in-memory GitHub backend implementing ghcli.API.
+//
+// It understands the GraphQL operations and REST endpoints issued by the
+// comments service, keeps threads, comments and reviews in memory, and applies
+// mutations to that state, so commands can run end to end without `gh` or a
+// network connection. A seed file can pre-populate pull requests for demos.
+package ghfake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agynio/gh-pr-review/internal/diff"
+	"github.com/agynio/gh-pr-review/internal/ghcli"
+)
+
+// Backend holds the simulated GitHub state.
+type Backend struct {
+	Host         string         `json:"host"`
+	Viewer       string         `json:"viewer"`
+	PullRequests []*PullRequest `json:"pull_requests"`
+	// GraphQLErrors are returned by every call of their operation, as GitHub
+	// does for fields the viewer cannot read.
+	GraphQLErrors []SeededError `json:"graphql_errors,omitempty"`
+
+	mu   sync.Mutex
+	seq  int
+	used int
+	now  func() time.Time
+}
+
+// SeededError is a GraphQL error injected into the responses of one operation.
+// The field at its path is nulled, so the rest of the response is returned as
+// partial data; without a path the whole request fails and is not applied.
+type SeededError struct {
+	Operation string `json:"operation"`
+	ghcli.GraphQLErrorEntry
+}
+
+// fakeRateLimit is the GraphQL quota the fake reports; every query costs one point.
+const fakeRateLimit = 5000
+
+// PullRequest is a simulated pull request.
+type PullRequest struct {
+	ID                string     `json:"id"`
+	Owner             string     `json:"owner"`
+	Repo              string     `json:"repo"`
+	Number            int        `json:"number"`
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	State             string     `json:"state"`
+	Author            string     `json:"author"`
+	AuthorAssociation string     `json:"author_association,omitempty"`
+	Labels            []string   `json:"labels,omitempty"`
+	BaseRef           string     `json:"base_ref"`
+	HeadRef           string     `json:"head_ref"`
+	HeadSHA           string     `json:"head_sha"`
+	CreatedAt         string     `json:"created_at"`
+	Files             []File     `json:"files"`
+	Commits           []Commit   `json:"commits"`
+	Threads           []*Thread  `json:"threads"`
+	Reviews           []*Review  `json:"reviews"`
+	IssueComments     []*Comment `json:"issue_comments"`
+}
+
+// File is a changed file with its unified diff patch and, optionally, its
+// content at the head commit for the contents endpoint.
+type File struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Commit is a pull request commit.
+type Commit struct {
+	SHA         string `json:"sha"`
+	Headline    string `json:"headline"`
+	Author      string `json:"author"`
+	CommittedAt string `json:"committed_at"`
+}
+
+// Thread is a review thread.
+type Thread struct {
+	ID                string     `json:"id"`
+	Path              string     `json:"path"`
+	Line              *int       `json:"line,omitempty"`
+	StartLine         *int       `json:"start_line,omitempty"`
+	OriginalLine      *int       `json:"original_line,omitempty"`
+	OriginalStartLine *int       `json:"original_start_line,omitempty"`
+	DiffSide          string     `json:"diff_side"`
+	StartDiffSide     string     `json:"start_diff_side,omitempty"`
+	IsResolved        bool       `json:"is_resolved"`
+	IsOutdated        bool       `json:"is_outdated"`
+	ResolvedBy        string     `json:"resolved_by,omitempty"`
+	Comments          []*Comment `json:"comments"`
+}
+
+// Comment is a review or conversation comment.
+type Comment struct {
+	ID                string         `json:"id"`
+	Body              string         `json:"body"`
+	Author            string         `json:"author"`
+	AuthorAssociation string         `json:"author_association,omitempty"`
+	CreatedAt         string         `json:"created_at"`
+	UpdatedAt         string         `json:"updated_at,omitempty"`
+	URL               string         `json:"url"`
+	DiffHunk          string         `json:"diff_hunk,omitempty"`
+	CommitSHA         string         `json:"commit_sha,omitempty"`
+	ReplyToID         string         `json:"reply_to_id,omitempty"`
+	IsMinimized       bool           `json:"is_minimized"`
+	MinimizedReason   string         `json:"minimized_reason,omitempty"`
+	Reactions         map[string]int `json:"reactions,omitempty"`
+}
+
+// Review is a pull request review.
+type Review struct {
+	ID                string `json:"id"`
+	State             string `json:"state"`
+	Body              string `json:"body"`
+	Author            string `json:"author"`
+	AuthorAssociation string `json:"author_association,omitempty"`
+	SubmittedAt       string `json:"submitted_at,omitempty"`
+	URL               string `json:"url"`
+}
+
+// New returns an empty backend for host acting as viewer.
+func New(host, viewer string) *Backend {
+	if host == "" {
+		host = "github.com"
+	}
+	return &Backend{Host: host, Viewer: viewer, now: time.Now}
+}
+
+// Load reads a seed file describing the initial state.
+func Load(path string) (*Backend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fake seed: %w", err)
+	}
+	b := New("", "")
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("parse fake seed: %w", err)
+	}
+	if b.Host == "" {
+		b.Host = "github.com"
+	}
+	if b.Viewer == "" {
+		b.Viewer = "octocat"
+	}
+	for _, pr := range b.PullRequests {
+		b.normalize(pr)
+	}
+	return b, nil
+}
+
+// AddPullRequest registers a pull request and fills in defaults.
+func (b *Backend) AddPullRequest(pr *PullRequest) *PullRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.normalize(pr)
+	b.PullRequests = append(b.PullRequests, pr)
+	return pr
+}
+
+func (b *Backend) normalize(pr *PullRequest) {
+	if pr.ID == "" {
+		pr.ID = b.nextID("PR")
+	}
+	if pr.State == "" {
+		pr.State = "OPEN"
+	}
+	if pr.CreatedAt == "" {
+		pr.CreatedAt = b.timestamp()
+	}
+	for _, t := range pr.Threads {
+		if t.ID == "" {
+			t.ID = b.nextID("PRRT")
+		}
+		if t.DiffSide == "" {
+			t.DiffSide = "RIGHT"
+		}
+		for _, c := range t.Comments {
+			b.normalizeComment(pr, c, "PRRC", "#discussion_r")
+		}
+	}
+	for _, c := range pr.IssueComments {
+		b.normalizeComment(pr, c, "IC", "#issuecomment-")
+	}
+	for _, r := range pr.Reviews {
+		if r.ID == "" {
+			r.ID = b.nextID("PRR")
+		}
+		if r.URL == "" {
+			r.URL = b.url(pr) + "#pullrequestreview-" + strconv.Itoa(b.seq)
+		}
+	}
+}
+
+func (b *Backend) normalizeComment(pr *PullRequest, c *Comment, prefix, anchor string) {
+	if c.ID == "" {
+		c.ID = b.nextID(prefix)
+	}
+	if c.CreatedAt == "" {
+		c.CreatedAt = b.timestamp()
+	}
+	if c.URL == "" {
+		c.URL = b.url(pr) + anchor + strconv.Itoa(b.seq)
+	}
+}
+
+func (b *Backend) url(pr *PullRequest) string {
+	return fmt.Sprintf("https://%s/%s/%s/pull/%d", b.Host, pr.Owner, pr.Repo, pr.Number)
+}
+
+func (b *Backend) nextID(prefix string) string {
+	b.seq++
+	return fmt.Sprintf("%s_fake%d", prefix, b.seq)
+}
+
+func (b *Backend) timestamp() string {
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	return now().UTC().Format(time.RFC3339)
+}
+
+// Client returns the backend as a ghcli.API.
+func (b *Backend) Client() ghcli.API {
+	return b
+}
+
+// GraphQL dispatches on the operation name of the query.
+func (b *Backend) GraphQL(query string, variables map[string]interface{}, result interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	vars := decodeVariables(variables)
+	op := ghcli.OperationName(query)
+	handler, ok := graphQLHandlers[op]
+	if !ok {
+		return &ghcli.GraphQLError{Errors: []ghcli.GraphQLErrorEntry{{Message: fmt.Sprintf("ghfake: unsupported operation %q", op)}}}
+	}
+	seeded := b.seededErrors(op)
+	for _, entry := range seeded {
+		// A request that fails as a whole is rejected before it changes any state.
+		if len(entry.Path) == 0 {
+			return &ghcli.GraphQLError{Errors: seeded}
+		}
+	}
+	data, err := handler(b, vars)
+	if err != nil {
+		return err
+	}
+	if fields, ok := data.(map[string]interface{}); ok && strings.Contains(query, "rateLimit") {
+		b.used++
+		fields["rateLimit"] = map[string]interface{}{
+			"cost":      1,
+			"remaining": fakeRateLimit - b.used,
+			"resetAt":   b.now().Add(time.Hour).UTC().Truncate(time.Hour).Format(time.RFC3339),
+		}
+	}
+	if len(seeded) > 0 {
+		return partialResponse(data, seeded, result)
+	}
+	return roundTrip(data, result)
+}
+
+func (b *Backend) seededErrors(op string) []ghcli.GraphQLErrorEntry {
+	var entries []ghcli.GraphQLErrorEntry
+	for _, e := range b.GraphQLErrors {
+		if e.Operation == op {
+			entries = append(entries, e.GraphQLErrorEntry)
+		}
+	}
+	return entries
+}
+
+// partialResponse nulls the field at each error's path and decodes what is
+// left, mirroring a GraphQL response that carries both data and errors.
+func partialResponse(data interface{}, entries []ghcli.GraphQLErrorEntry, result interface{}) error {
+	var generic interface{}
+	if err := roundTrip(data, &generic); err != nil {
+		return err
+	}
+	gqlErr := &ghcli.GraphQLError{Errors: entries}
+	for _, entry := range entries {
+		nullPath(generic, entry.Path)
+	}
+	if err := roundTrip(generic, result); err != nil {
+		return err
+	}
+	gqlErr.Partial = true
+	return gqlErr
+}
+
+func nullPath(node interface{}, path []interface{}) {
+	for i, key := range path {
+		last := i == len(path)-1
+		switch v := node.(type) {
+		case map[string]interface{}:
+			name, _ := key.(string)
+			if _, ok := v[name]; !ok {
+				return
+			}
+			if last {
+				v[name] = nil
+				return
+			}
+			node = v[name]
+		case []interface{}:
+			index, ok := key.(float64)
+			if !ok || int(index) < 0 || int(index) >= len(v) {
+				return
+			}
+			if last {
+				v[int(index)] = nil
+				return
+			}
+			node = v[int(index)]
+		default:
+			return
+		}
+	}
+}
+
+// REST serves the REST endpoints used by the comments service.
+func (b *Backend) REST(method, path string, params map[string]string, body interface{}, result interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if strings.EqualFold(method, "GET") && len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "files" {
+		number, _ := strconv.Atoi(parts[4])
+		pr := b.find(parts[1], parts[2], number)
+		if pr == nil {
+			return &ghcli.APIError{StatusCode: 404, Message: "Not Found"}
+		}
+		files := make([]map[string]interface{}, 0)
+		if params["page"] == "" || params["page"] == "1" {
+			for _, f := range pr.Files {
+				files = append(files, map[string]interface{}{"filename": f.Filename, "patch": f.Patch})
+			}
+		}
+		return roundTrip(files, result)
+	}
+
+	if strings.EqualFold(method, "GET") && len(parts) > 4 && parts[0] == "repos" && parts[3] == "contents" {
+		for _, pr := range b.PullRequests {
+			if pr.Owner != parts[1] || pr.Repo != parts[2] {
+				continue
+			}
+			for _, f := range pr.Files {
+				if f.Filename == strings.Join(parts[4:], "/") && f.Content != "" {
+					return roundTrip(map[string]interface{}{
+						"type":     "file",
+						"encoding": "base64",
+						"content":  base64.StdEncoding.EncodeToString([]byte(f.Content)),
+					}, result)
+				}
+			}
+		}
+		return &ghcli.APIError{StatusCode: 404, Message: "Not Found"}
+	}
+
+	return &ghcli.APIError{StatusCode: 404, Message: fmt.Sprintf("ghfake: unsupported endpoint %s %s", method, path)}
+}
+
+// RunGh answers the `gh pr view` / `gh repo view` calls made by the resolver.
+func (b *Backend) RunGh(args ...string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(args) < 2 {
+		return nil, fmt.Errorf("ghfake: unsupported command gh %s", strings.Join(args, " "))
+	}
+
+	var selector, repo string
+	for i := 2; i < len(args); i++ {
+		switch args[i] {
+		case "--repo", "--json":
+			if args[i] == "--repo" && i+1 < len(args) {
+				repo = args[i+1]
+			}
+			i++
+		default:
+			selector = args[i]
+		}
+	}
+
+	switch args[0] + " " + args[1] {
+	case "pr view":
+		pr := b.selectPullRequest(selector, repo)
+		if pr == nil {
+			return nil, fmt.Errorf("no pull requests found for %q", selector)
+		}
+		return json.Marshal(map[string]string{"url": b.url(pr)})
+	case "repo view":
+		if len(b.PullRequests) == 0 {
+			return nil, errors.New("ghfake: no repositories")
+		}
+		pr := b.PullRequests[0]
+		return json.Marshal(map[string]string{"url": fmt.Sprintf("https://%s/%s/%s", b.Host, pr.Owner, pr.Repo)})
+	}
+	return nil, fmt.Errorf("ghfake: unsupported command gh %s", strings.Join(args, " "))
+}
+
+func (b *Backend) selectPullRequest(selector, repo string) *PullRequest {
+	for _, pr := range b.PullRequests {
+		if repo != "" && !strings.EqualFold(repo, pr.Owner+"/"+pr.Repo) {
+			continue
+		}
+		switch {
+		case selector == "":
+			return pr
+		case selector == strconv.Itoa(pr.Number), selector == b.url(pr), selector == pr.HeadRef:
+			return pr
+		}
+	}
+	return nil
+}
+
+func (b *Backend) find(owner, repo string, number int) *PullRequest {
+	for _, pr := range b.PullRequests {
+		if strings.EqualFold(pr.Owner, owner) && strings.EqualFold(pr.Repo, repo) && pr.Number == number {
+			return pr
+		}
+	}
+	return nil
+}
+
+func (b *Backend) findByID(id string) *PullRequest {
+	for _, pr := range b.PullRequests {
+		if pr.ID == id {
+			return pr
+		}
+	}
+	return nil
+}
+
+// findComment locates a review or conversation comment by node ID.
+func (b *Backend) findComment(id string) (*PullRequest, *Thread, *Comment) {
+	for _, pr := range b.PullRequests {
+		for _, t := range pr.Threads {
+			for _, c := range t.Comments {
+				if c.ID == id {
+					return pr, t, c
+				}
+			}
+		}
+		for _, c := range pr.IssueComments {
+			if c.ID == id {
+				return pr, nil, c
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+func (b *Backend) findThread(id string) (*PullRequest, *Thread) {
+	for _, pr := range b.PullRequests {
+		for _, t := range pr.Threads {
+			if t.ID == id {
+				return pr, t
+			}
+		}
+	}
+	return nil, nil
+}
+
+// diffHunk builds the diff hunk GitHub attaches to a new comment: the hunk
+// containing the line, truncated at that line.
+func diffHunk(pr *PullRequest, path, side string, line int) string {
+	for _, f := range pr.Files {
+		if f.Filename != path {
+			continue
+		}
+		raw := strings.Split(f.Patch, "\n")
+		header := -1
+		for i, l := range raw {
+			if strings.HasPrefix(l, "@@") {
+				header = i
+			}
+			if header < 0 {
+				continue
+			}
+			hunks := diff.Parse(strings.Join(raw[header:i+1], "\n"))
+			if len(hunks) == 0 || len(hunks[0].Lines) == 0 {
+				continue
+			}
+			last := hunks[0].Lines[len(hunks[0].Lines)-1]
+			if diff.LineNumber(last, side) == line && i > header {
+				return strings.Join(raw[header:i+1], "\n")
+			}
+		}
+	}
+	return ""
+}
+
+func decodeVariables(variables map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return variables
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return variables
+	}
+	return decoded
+}
+
+func roundTrip(data interface{}, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("ghfake: marshal response: %w", err)
+	}
+	if err := json.Unmarshal(encoded, result); err != nil {
+		return fmt.Errorf("ghfake: unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func notFound(what string) error {
+	return &ghcli.GraphQLError{Errors: []ghcli.GraphQLErrorEntry{{Type: ghcli.GraphQLNotFound, Message: fmt.Sprintf("Could not resolve to a node with the global id of '%s'", what)}}}
+}
+
+func stringVar(vars map[string]interface{}, key string) string {
+	if v, ok := vars[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func intVar(vars map[string]interface{}, key string) int {
+	if v, ok := vars[key].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+func intPtrVar(vars map[string]interface{}, key string) *int {
+	if v, ok := vars[key].(float64); ok {
+		n := int(v)
+		return &n
+	}
+	return nil
+}
+
+func mapVar(vars map[string]interface{}, key string) map[string]interface{} {
+	if v, ok := vars[key].(map[string]interface{}); ok {
+		return v
+	}
+	return map[string]interface{}{}
+}
+
+func graphQLError(message string) error {
+	return &ghcli.GraphQLError{Errors: []ghcli.GraphQLErrorEntry{{Message: message}}}
+}
@@ -0,0 +1,600 @@
+package ghfake
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type graphQLHandler func(b *Backend, vars map[string]interface{}) (interface{}, error)
+
+// graphQLHandlers maps operation names used by the comments service to handlers.
+var graphQLHandlers = map[string]graphQLHandler{
+	"PullRequestInlineComments":       (*Backend).listThreads,
+	"PullRequestNode":                 (*Backend).pullRequestNode,
+	"AddPullRequestReviewThread":      (*Backend).addThread,
+	"PullRequestReviews":              (*Backend).listReviews,
+	"PullRequestIssueComments":        (*Backend).listIssueComments,
+	"MinimizeComment":                 (*Backend).minimizeComment,
+	"UnminimizeComment":               (*Backend).unminimizeComment,
+	"AddPullRequestReview":            (*Backend).addReview,
+	"SearchPullRequests":              (*Backend).searchPullRequests,
+	"PullRequestExport":               (*Backend).exportPullRequest,
+	"AddPullRequestReviewThreadReply": (*Backend).addReply,
+	"ResolveReviewThread":             (*Backend).resolveThread,
+	"UnresolveReviewThread":           (*Backend).unresolveThread,
+	"AddReaction":                     (*Backend).addReaction,
+	"UpdatePullRequestReviewComment":  (*Backend).updateComment,
+	"DeletePullRequestReviewComment":  (*Backend).deleteComment,
+	"PullRequestCommits":              (*Backend).listCommits,
+	"ReviewThreadAnchor":              (*Backend).threadAnchor,
+}
+
+func (b *Backend) pullRequestFor(vars map[string]interface{}) *PullRequest {
+	return b.find(stringVar(vars, "owner"), stringVar(vars, "name"), intVar(vars, "number"))
+}
+
+// repository wraps a pull request payload in the repository/pullRequest shape,
+// returning a null pullRequest when it does not exist.
+func repository(pr map[string]interface{}) map[string]interface{} {
+	var pullRequest interface{}
+	if pr != nil {
+		pullRequest = pr
+	}
+	return map[string]interface{}{"repository": map[string]interface{}{"pullRequest": pullRequest}}
+}
+
+func (b *Backend) listThreads(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	return repository(map[string]interface{}{
+		"reviewThreads": map[string]interface{}{"nodes": threadNodes(pr.Threads)},
+	}), nil
+}
+
+func (b *Backend) pullRequestNode(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	return repository(map[string]interface{}{"id": pr.ID}), nil
+}
+
+func (b *Backend) addThread(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	pr := b.findByID(stringVar(input, "pullRequestId"))
+	if pr == nil {
+		return nil, notFound(stringVar(input, "pullRequestId"))
+	}
+	thread, err := b.newThread(pr, input, "")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"addPullRequestReviewThread": map[string]interface{}{"thread": threadNode(thread)},
+	}, nil
+}
+
+func (b *Backend) newThread(pr *PullRequest, input map[string]interface{}, body string) (*Thread, error) {
+	path := stringVar(input, "path")
+	line := intPtrVar(input, "line")
+	if path == "" || line == nil {
+		return nil, graphQLError("path and line are required")
+	}
+	side := strings.ToUpper(stringVar(input, "side"))
+	if side == "" {
+		side = "RIGHT"
+	}
+	if body == "" {
+		body = stringVar(input, "body")
+	}
+
+	hunk := diffHunk(pr, path, side, *line)
+	if hunk == "" {
+		return nil, graphQLError("Line could not be resolved")
+	}
+
+	thread := &Thread{
+		ID:                b.nextID("PRRT"),
+		Path:              path,
+		Line:              line,
+		StartLine:         intPtrVar(input, "startLine"),
+		OriginalLine:      line,
+		OriginalStartLine: intPtrVar(input, "startLine"),
+		DiffSide:          side,
+		StartDiffSide:     strings.ToUpper(stringVar(input, "startSide")),
+	}
+	comment := &Comment{Body: body, Author: b.Viewer, DiffHunk: hunk, CommitSHA: pr.HeadSHA}
+	b.normalizeComment(pr, comment, "PRRC", "#discussion_r")
+	thread.Comments = []*Comment{comment}
+	pr.Threads = append(pr.Threads, thread)
+	return thread, nil
+}
+
+func (b *Backend) listReviews(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	return repository(map[string]interface{}{
+		"reviews": page(reviewNodes(pr.Reviews), vars, "first"),
+	}), nil
+}
+
+func (b *Backend) listIssueComments(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	nodes := make([]map[string]interface{}, 0, len(pr.IssueComments))
+	for _, c := range pr.IssueComments {
+		nodes = append(nodes, commentNode(c))
+	}
+	return repository(map[string]interface{}{
+		"comments": page(nodes, vars, "first"),
+	}), nil
+}
+
+var minimizeReasons = map[string]string{
+	"OUTDATED":  "outdated",
+	"RESOLVED":  "resolved",
+	"DUPLICATE": "duplicate",
+	"OFF_TOPIC": "off-topic",
+	"SPAM":      "spam",
+	"ABUSE":     "abuse",
+}
+
+func (b *Backend) minimizeComment(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	_, _, comment := b.findComment(stringVar(input, "subjectId"))
+	if comment == nil {
+		return nil, notFound(stringVar(input, "subjectId"))
+	}
+	reason, ok := minimizeReasons[stringVar(input, "classifier")]
+	if !ok {
+		return nil, graphQLError("invalid classifier")
+	}
+	comment.IsMinimized = true
+	comment.MinimizedReason = reason
+	return map[string]interface{}{
+		"minimizeComment": map[string]interface{}{"minimizedComment": commentNode(comment)},
+	}, nil
+}
+
+func (b *Backend) unminimizeComment(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	_, _, comment := b.findComment(stringVar(input, "subjectId"))
+	if comment == nil {
+		return nil, notFound(stringVar(input, "subjectId"))
+	}
+	comment.IsMinimized = false
+	comment.MinimizedReason = ""
+	return map[string]interface{}{
+		"unminimizeComment": map[string]interface{}{"unminimizedComment": commentNode(comment)},
+	}, nil
+}
+
+func (b *Backend) addReply(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	id := stringVar(input, "pullRequestReviewThreadId")
+	pr, thread := b.findThread(id)
+	if thread == nil {
+		return nil, notFound(id)
+	}
+	body := stringVar(input, "body")
+	if strings.TrimSpace(body) == "" {
+		return nil, graphQLError("body can't be blank")
+	}
+	comment := &Comment{Body: body, Author: b.Viewer, CommitSHA: pr.HeadSHA}
+	if len(thread.Comments) > 0 {
+		comment.DiffHunk = thread.Comments[0].DiffHunk
+		comment.ReplyToID = thread.Comments[0].ID
+	}
+	b.normalizeComment(pr, comment, "PRRC", "#discussion_r")
+	thread.Comments = append(thread.Comments, comment)
+	return map[string]interface{}{
+		"addPullRequestReviewThreadReply": map[string]interface{}{"comment": commentNode(comment)},
+	}, nil
+}
+
+func (b *Backend) resolveThread(vars map[string]interface{}) (interface{}, error) {
+	return b.setResolved(vars, "resolveReviewThread", true)
+}
+
+func (b *Backend) unresolveThread(vars map[string]interface{}) (interface{}, error) {
+	return b.setResolved(vars, "unresolveReviewThread", false)
+}
+
+func (b *Backend) setResolved(vars map[string]interface{}, field string, resolved bool) (interface{}, error) {
+	input := mapVar(vars, "input")
+	id := stringVar(input, "threadId")
+	_, thread := b.findThread(id)
+	if thread == nil {
+		return nil, notFound(id)
+	}
+	thread.IsResolved = resolved
+	thread.ResolvedBy = ""
+	if resolved {
+		thread.ResolvedBy = b.Viewer
+	}
+	return map[string]interface{}{field: map[string]interface{}{"thread": threadNode(thread)}}, nil
+}
+
+var reactionContents = map[string]bool{
+	"THUMBS_UP": true, "THUMBS_DOWN": true, "LAUGH": true, "HOORAY": true,
+	"CONFUSED": true, "HEART": true, "ROCKET": true, "EYES": true,
+}
+
+func (b *Backend) addReaction(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	id := stringVar(input, "subjectId")
+	_, _, comment := b.findComment(id)
+	if comment == nil {
+		return nil, notFound(id)
+	}
+	content := stringVar(input, "content")
+	if !reactionContents[content] {
+		return nil, graphQLError("invalid reaction content")
+	}
+	if comment.Reactions == nil {
+		comment.Reactions = make(map[string]int)
+	}
+	comment.Reactions[content]++
+	return map[string]interface{}{
+		"addReaction": map[string]interface{}{
+			"reaction": map[string]interface{}{"content": content},
+			"subject":  map[string]interface{}{"id": comment.ID},
+		},
+	}, nil
+}
+
+func (b *Backend) updateComment(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	id := stringVar(input, "pullRequestReviewCommentId")
+	_, thread, comment := b.findComment(id)
+	if thread == nil {
+		return nil, notFound(id)
+	}
+	body := stringVar(input, "body")
+	if strings.TrimSpace(body) == "" {
+		return nil, graphQLError("body can't be blank")
+	}
+	comment.Body = body
+	comment.UpdatedAt = b.timestamp()
+	return map[string]interface{}{
+		"updatePullRequestReviewComment": map[string]interface{}{"pullRequestReviewComment": commentNode(comment)},
+	}, nil
+}
+
+// deleteComment removes a review comment, and its thread when no comments remain.
+func (b *Backend) deleteComment(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	id := stringVar(input, "id")
+	pr, thread, comment := b.findComment(id)
+	if thread == nil {
+		return nil, notFound(id)
+	}
+	kept := thread.Comments[:0]
+	for _, c := range thread.Comments {
+		if c != comment {
+			kept = append(kept, c)
+		}
+	}
+	thread.Comments = kept
+	if len(kept) == 0 {
+		threads := pr.Threads[:0]
+		for _, t := range pr.Threads {
+			if t != thread {
+				threads = append(threads, t)
+			}
+		}
+		pr.Threads = threads
+	}
+	return map[string]interface{}{
+		"deletePullRequestReviewComment": map[string]interface{}{"pullRequestReview": nil},
+	}, nil
+}
+
+func (b *Backend) addReview(vars map[string]interface{}) (interface{}, error) {
+	input := mapVar(vars, "input")
+	pr := b.findByID(stringVar(input, "pullRequestId"))
+	if pr == nil {
+		return nil, notFound(stringVar(input, "pullRequestId"))
+	}
+
+	threads, _ := input["threads"].([]interface{})
+	for _, raw := range threads {
+		threadInput, _ := raw.(map[string]interface{})
+		if _, err := b.newThread(pr, threadInput, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	state := "COMMENTED"
+	switch strings.ToUpper(stringVar(input, "event")) {
+	case "APPROVE":
+		state = "APPROVED"
+	case "REQUEST_CHANGES":
+		state = "CHANGES_REQUESTED"
+	case "":
+		state = "PENDING"
+	}
+	review := &Review{State: state, Body: stringVar(input, "body"), Author: b.Viewer}
+	if state != "PENDING" {
+		review.SubmittedAt = b.timestamp()
+	}
+	review.ID = b.nextID("PRR")
+	review.URL = b.url(pr) + "#pullrequestreview-" + strconv.Itoa(b.seq)
+	pr.Reviews = append(pr.Reviews, review)
+
+	node := reviewNode(review)
+	node["comments"] = map[string]interface{}{"totalCount": len(threads)}
+	return map[string]interface{}{
+		"addPullRequestReview": map[string]interface{}{"pullRequestReview": node},
+	}, nil
+}
+
+var searchTermRE = regexp.MustCompile(`(\w[\w-]*):("[^"]*"|\S+)`)
+
+func (b *Backend) searchPullRequests(vars map[string]interface{}) (interface{}, error) {
+	nodes := make([]map[string]interface{}, 0)
+	for _, pr := range b.PullRequests {
+		if matchesSearch(pr, stringVar(vars, "query")) {
+			nodes = append(nodes, map[string]interface{}{
+				"number":            pr.Number,
+				"url":               b.url(pr),
+				"title":             pr.Title,
+				"state":             pr.State,
+				"author":            authorNode(pr.Author),
+				"authorAssociation": association(pr.AuthorAssociation),
+			})
+		}
+	}
+	return map[string]interface{}{"search": page(nodes, vars, "first")}, nil
+}
+
+func matchesSearch(pr *PullRequest, query string) bool {
+	for _, m := range searchTermRE.FindAllStringSubmatch(query, -1) {
+		value := strings.Trim(m[2], `"`)
+		switch m[1] {
+		case "repo":
+			if !strings.EqualFold(value, pr.Owner+"/"+pr.Repo) {
+				return false
+			}
+		case "is":
+			switch value {
+			case "open", "closed", "merged":
+				if !strings.EqualFold(value, pr.State) {
+					return false
+				}
+			}
+		case "author":
+			if !strings.EqualFold(value, pr.Author) {
+				return false
+			}
+		case "reviewed-by":
+			reviewed := false
+			for _, r := range pr.Reviews {
+				if strings.EqualFold(r.Author, value) {
+					reviewed = true
+				}
+			}
+			if !reviewed {
+				return false
+			}
+		case "label":
+			labeled := false
+			for _, l := range pr.Labels {
+				if strings.EqualFold(l, value) {
+					labeled = true
+				}
+			}
+			if !labeled {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *Backend) exportPullRequest(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	commits := make([]map[string]interface{}, 0, len(pr.Commits))
+	for _, c := range pr.Commits {
+		commits = append(commits, map[string]interface{}{
+			"commit": map[string]interface{}{
+				"oid":             c.SHA,
+				"messageHeadline": c.Headline,
+				"committedDate":   c.CommittedAt,
+				"url":             fmt.Sprintf("https://%s/%s/%s/commit/%s", b.Host, pr.Owner, pr.Repo, c.SHA),
+				"author":          map[string]interface{}{"name": c.Author, "user": authorNode(c.Author)},
+			},
+		})
+	}
+	return repository(map[string]interface{}{
+		"title":             pr.Title,
+		"body":              pr.Body,
+		"state":             pr.State,
+		"createdAt":         pr.CreatedAt,
+		"baseRefName":       pr.BaseRef,
+		"headRefName":       pr.HeadRef,
+		"headRefOid":        pr.HeadSHA,
+		"author":            authorNode(pr.Author),
+		"authorAssociation": association(pr.AuthorAssociation),
+		"commits":           map[string]interface{}{"nodes": commits},
+		"reviewThreads":     map[string]interface{}{"nodes": threadNodes(pr.Threads)},
+	}), nil
+}
+
+// listCommits returns the seeded commits, plus the head commit when it is not among them.
+func (b *Backend) listCommits(vars map[string]interface{}) (interface{}, error) {
+	pr := b.pullRequestFor(vars)
+	if pr == nil {
+		return repository(nil), nil
+	}
+	commits := make([]map[string]interface{}, 0, len(pr.Commits)+1)
+	head := false
+	for _, c := range pr.Commits {
+		head = head || c.SHA == pr.HeadSHA
+		commits = append(commits, b.commitNode(pr, c.SHA))
+	}
+	if !head && pr.HeadSHA != "" {
+		commits = append(commits, b.commitNode(pr, pr.HeadSHA))
+	}
+	return repository(map[string]interface{}{
+		"baseRefName": pr.BaseRef,
+		"headRefName": pr.HeadRef,
+		"headRefOid":  pr.HeadSHA,
+		"commits":     page(commits, vars, "firstCommits"),
+	}), nil
+}
+
+// page returns one page of a connection, sized by the variable named first.
+// Cursors are offsets into nodes.
+func page(nodes []map[string]interface{}, vars map[string]interface{}, first string) map[string]interface{} {
+	start, _ := strconv.Atoi(stringVar(vars, "after"))
+	if start > len(nodes) {
+		start = len(nodes)
+	}
+	end := len(nodes)
+	if size := intVar(vars, first); size > 0 && start+size < end {
+		end = start + size
+	}
+	return map[string]interface{}{
+		"pageInfo": map[string]interface{}{"hasNextPage": end < len(nodes), "endCursor": strconv.Itoa(end)},
+		"nodes":    nodes[start:end],
+	}
+}
+
+func (b *Backend) threadAnchor(vars map[string]interface{}) (interface{}, error) {
+	pr, thread := b.findThread(stringVar(vars, "id"))
+	if thread == nil {
+		return map[string]interface{}{"node": nil}, nil
+	}
+	node := threadNode(thread)
+	node["pullRequest"] = map[string]interface{}{"number": pr.Number, "headRefOid": pr.HeadSHA}
+	return map[string]interface{}{"node": node}, nil
+}
+
+func (b *Backend) commitNode(pr *PullRequest, sha string) map[string]interface{} {
+	return map[string]interface{}{
+		"commit": map[string]interface{}{
+			"oid": sha,
+			"url": fmt.Sprintf("https://%s/%s/%s/commit/%s", b.Host, pr.Owner, pr.Repo, sha),
+		},
+	}
+}
+
+func threadNodes(threads []*Thread) []map[string]interface{} {
+	nodes := make([]map[string]interface{}, 0, len(threads))
+	for _, t := range threads {
+		nodes = append(nodes, threadNode(t))
+	}
+	return nodes
+}
+
+func threadNode(t *Thread) map[string]interface{} {
+	comments := make([]map[string]interface{}, 0, len(t.Comments))
+	for _, c := range t.Comments {
+		comments = append(comments, commentNode(c))
+	}
+	var resolvedBy interface{}
+	if t.ResolvedBy != "" {
+		resolvedBy = authorNode(t.ResolvedBy)
+	}
+	var startDiffSide interface{}
+	if t.StartDiffSide != "" {
+		startDiffSide = t.StartDiffSide
+	}
+	return map[string]interface{}{
+		"id":                t.ID,
+		"path":              t.Path,
+		"line":              t.Line,
+		"startLine":         t.StartLine,
+		"originalLine":      t.OriginalLine,
+		"originalStartLine": t.OriginalStartLine,
+		"diffSide":          t.DiffSide,
+		"startDiffSide":     startDiffSide,
+		"isResolved":        t.IsResolved,
+		"isOutdated":        t.IsOutdated,
+		"resolvedBy":        resolvedBy,
+		"comments":          map[string]interface{}{"nodes": comments},
+	}
+}
+
+func commentNode(c *Comment) map[string]interface{} {
+	node := map[string]interface{}{
+		"id":                c.ID,
+		"body":              c.Body,
+		"createdAt":         c.CreatedAt,
+		"updatedAt":         c.UpdatedAt,
+		"url":               c.URL,
+		"diffHunk":          c.DiffHunk,
+		"isMinimized":       c.IsMinimized,
+		"author":            authorNode(c.Author),
+		"authorAssociation": association(c.AuthorAssociation),
+	}
+	if c.MinimizedReason != "" {
+		node["minimizedReason"] = c.MinimizedReason
+	} else {
+		node["minimizedReason"] = nil
+	}
+	if c.CommitSHA != "" {
+		node["commit"] = map[string]interface{}{"oid": c.CommitSHA}
+		node["originalCommit"] = map[string]interface{}{"oid": c.CommitSHA}
+	}
+	if c.ReplyToID != "" {
+		node["replyTo"] = map[string]interface{}{"id": c.ReplyToID}
+	}
+	return node
+}
+
+func reviewNodes(reviews []*Review) []map[string]interface{} {
+	nodes := make([]map[string]interface{}, 0, len(reviews))
+	for _, r := range reviews {
+		nodes = append(nodes, reviewNode(r))
+	}
+	return nodes
+}
+
+func reviewNode(r *Review) map[string]interface{} {
+	var submittedAt interface{}
+	if r.SubmittedAt != "" {
+		submittedAt = r.SubmittedAt
+	}
+	return map[string]interface{}{
+		"id":                r.ID,
+		"state":             r.State,
+		"body":              r.Body,
+		"submittedAt":       submittedAt,
+		"url":               r.URL,
+		"author":            authorNode(r.Author),
+		"authorAssociation": association(r.AuthorAssociation),
+	}
+}
+
+// authorNode returns null for an empty login, matching GitHub's response for
+// deleted ("ghost") accounts. Logins ending in "[bot]" are reported as bots
+// under their GraphQL login, which omits the suffix.
+func authorNode(login string) interface{} {
+	if login == "" {
+		return nil
+	}
+	if name, ok := strings.CutSuffix(login, "[bot]"); ok {
+		return map[string]interface{}{"login": name, "__typename": "Bot"}
+	}
+	return map[string]interface{}{"login": login, "__typename": "User"}
+}
+
+func association(value string) string {
+	if value == "" {
+		return "NONE"
+	}
+	return value
+}
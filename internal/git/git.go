@@ -0,0 +1,121 @@
+// Package git runs read-only queries against the local repository.
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var runGit = func(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+				return nil, errors.New(msg)
+			}
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// UseRunner replaces the function used to invoke `git` and returns the previous one.
+func UseRunner(run func(args ...string) ([]byte, error)) func(args ...string) ([]byte, error) {
+	previous := runGit
+	runGit = run
+	return previous
+}
+
+// RevParse resolves a revision such as HEAD or a short SHA to a full commit SHA.
+func RevParse(rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return "", errors.New("revision is required")
+	}
+	output, err := runGit("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", errors.New("unknown revision " + rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommitTrailers lists the values of one trailer key found in a commit message.
+type CommitTrailers struct {
+	SHA    string
+	Values []string
+}
+
+// Trailers returns the commits in revRange, oldest first, whose messages carry
+// the trailer key (matched case-insensitively).
+func Trailers(revRange, key string) ([]CommitTrailers, error) {
+	format := "--format=%H%x1f%(trailers:key=" + key + ",valueonly,separator=%x1f)%x1e"
+	output, err := runGit("log", "--reverse", format, "--end-of-options", revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitTrailers
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) < 2 {
+			continue
+		}
+		commit := CommitTrailers{SHA: fields[0]}
+		for _, value := range fields[1:] {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					commit.Values = append(commit.Values, v)
+				}
+			}
+		}
+		if len(commit.Values) > 0 {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// Path resolves a path inside the repository's git directory, like `git rev-parse --git-path`.
+func Path(name string) (string, error) {
+	output, err := runGit("rev-parse", "--path-format=absolute", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HasCommit reports whether the commit exists in the local object database.
+func HasCommit(sha string) bool {
+	_, err := runGit("cat-file", "-e", "--end-of-options", sha+"^{commit}")
+	return err == nil
+}
+
+// Fetch fetches refspecs from remote without updating any local branches.
+func Fetch(remote string, refspecs ...string) error {
+	args := append([]string{"fetch", "--quiet", "--no-tags", "--end-of-options", remote}, refspecs...)
+	_, err := runGit(args...)
+	return err
+}
+
+// Show returns the content of path at commit.
+func Show(commit, path string) (string, error) {
+	output, err := runGit("show", "--no-textconv", commit+":"+path)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// Diff returns the unified diff of path between two commits with the given
+// number of context lines.
+func Diff(from, to, path string, context int) (string, error) {
+	output, err := runGit("diff", "--no-color", "--no-ext-diff", fmt.Sprintf("-U%d", context), from, to, "--", path)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
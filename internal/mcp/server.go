@@ -0,0 +1,238 @@
+// Package mcp implements the stdio transport of the Model Context Protocol:
+// newline-delimited JSON-RPC 2.0 messages, with just the tools capability.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Protocol versions the server can speak, newest first.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Handler executes a tool call. The returned value is serialized as the tool's
+// structured result; an error is reported to the client as a failed tool call.
+type Handler func(arguments json.RawMessage) (interface{}, error)
+
+// Tool describes a callable tool and its JSON Schema input.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Handler     Handler                `json:"-"`
+}
+
+// Server dispatches MCP requests to registered tools.
+type Server struct {
+	Name    string
+	Version string
+
+	tools []Tool
+	index map[string]int
+}
+
+// NewServer creates a server that identifies itself with name and version.
+func NewServer(name, version string) *Server {
+	return &Server{Name: name, Version: version, index: make(map[string]int)}
+}
+
+// AddTool registers a tool; a later tool with the same name replaces the earlier one.
+func (s *Server) AddTool(tool Tool) {
+	if i, ok := s.index[tool.Name]; ok {
+		s.tools[i] = tool
+		return
+	}
+	s.index[tool.Name] = len(s.tools)
+	s.tools = append(s.tools, tool)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from in until EOF and writes responses to out. Requests
+// are handled one at a time, in order.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(line); resp != nil {
+				if writeErr := writeResponse(out, resp); writeErr != nil {
+					return writeErr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read mcp message: %w", err)
+		}
+	}
+}
+
+func writeResponse(out io.Writer, resp *response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode mcp response: %w", err)
+	}
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write mcp response: %w", err)
+	}
+	return nil
+}
+
+// handle processes one message and returns the response, or nil for notifications.
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error"}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, codeInvalidRequest, "invalid request")
+	}
+
+	notification := len(req.ID) == 0
+	result, rpcErr := s.dispatch(req)
+	if notification {
+		return nil
+	}
+	if rpcErr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func (s *Server) dispatch(req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	}
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &init); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params"}
+		}
+	}
+
+	version := supportedVersions[0]
+	for _, v := range supportedVersions {
+		if v == init.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{"listChanged": false},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    s.Name,
+			"version": s.Version,
+		},
+	}, nil
+}
+
+func (s *Server) callTool(params json.RawMessage) (interface{}, *rpcError) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+	}
+	i, ok := s.index[call.Name]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", call.Name)}
+	}
+	if len(call.Arguments) == 0 || string(call.Arguments) == "null" {
+		call.Arguments = json.RawMessage("{}")
+	}
+
+	value, err := runTool(s.tools[i], call.Arguments)
+	if err != nil {
+		return toolResult(err.Error(), nil, true), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return toolResult(fmt.Sprintf("encode result: %v", err), nil, true), nil
+	}
+	return toolResult(string(data), value, false), nil
+}
+
+// runTool calls the tool's handler, turning a panic into an error so one failing
+// call is reported as a tool error instead of ending the session.
+func runTool(tool Tool, arguments json.RawMessage) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, fmt.Errorf("tool %s failed: %v", tool.Name, r)
+		}
+	}()
+	return tool.Handler(arguments)
+}
+
+// toolResult builds a CallToolResult with the JSON as text content, and as
+// structured content when it is an object.
+func toolResult(text string, value interface{}, isError bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+	if _, ok := value.(map[string]interface{}); ok {
+		result["structuredContent"] = value
+	}
+	return result
+}
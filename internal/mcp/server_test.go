@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, server *Server, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := server.Serve(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var responses []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]interface{}
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestPanickingToolReportsError(t *testing.T) {
+	server := NewServer("test", "0.0.0")
+	server.AddTool(Tool{Name: "boom", Handler: func(json.RawMessage) (interface{}, error) {
+		var m map[string]int
+		m["crash"]++
+		return nil, nil
+	}})
+	server.AddTool(Tool{Name: "echo", Handler: func(arguments json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}})
+
+	responses := serve(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"boom"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`,
+	)
+	if len(responses) != 2 {
+		t.Fatalf("got %d responses, want 2", len(responses))
+	}
+
+	failed := responses[0]["result"].(map[string]interface{})
+	if failed["isError"] != true {
+		t.Fatalf("panicking tool result = %v, want isError", failed)
+	}
+	text := failed["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "tool boom failed") {
+		t.Fatalf("error text = %q", text)
+	}
+
+	if ok := responses[1]["result"].(map[string]interface{}); ok["isError"] != false {
+		t.Fatalf("session did not survive the panic: %v", ok)
+	}
+}
@@ -35,6 +35,14 @@ var runGh = func(args ...string) ([]byte, error) {
 	return output, nil
 }
 
+// UseRunner replaces the function used to invoke `gh` (for example with a
+// fixture replayer or fake backend) and returns the previous one.
+func UseRunner(run func(args ...string) ([]byte, error)) func(args ...string) ([]byte, error) {
+	previous := runGh
+	runGh = run
+	return previous
+}
+
 // Resolve infers the pull request identity similarly to `gh pr view`.
 //
 // Priority:
@@ -107,6 +115,16 @@ func normalizeSelector(selector string, prFlag int) (string, error) {
 	return selector, nil
 }
 
+// FromURL builds an Identity from a pull request URL without calling gh.
+func FromURL(raw string) (Identity, error) {
+	identity, err := parsePullURL(raw)
+	if err != nil {
+		return Identity{}, err
+	}
+	identity.URL = raw
+	return identity, nil
+}
+
 func parsePullURL(raw string) (Identity, error) {
 	u, err := url.Parse(raw)
 	if err != nil {
@@ -130,3 +148,55 @@ func parsePullURL(raw string) (Identity, error) {
 		Number: number,
 	}, nil
 }
+
+// Repository represents a fully-resolved repository reference.
+type Repository struct {
+	Owner string
+	Repo  string
+	Host  string
+}
+
+// ResolveRepository infers the repository similarly to `gh repo view`.
+//
+// An explicit --repo value in `owner/repo` or `host/owner/repo` form is parsed
+// directly; otherwise the repository of the current directory is used.
+func ResolveRepository(repoFlag string) (Repository, error) {
+	if repo := strings.TrimSpace(repoFlag); repo != "" {
+		return parseRepository(repo)
+	}
+
+	output, err := runGh("repo", "view", "--json", "url")
+	if err != nil {
+		return Repository{}, fmt.Errorf("resolve repository via gh repo view: %w", err)
+	}
+
+	var payload struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(output, &payload); err != nil {
+		return Repository{}, fmt.Errorf("parse gh repo view output: %w", err)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(payload.URL))
+	if err != nil || u.Host == "" {
+		return Repository{}, fmt.Errorf("parse repository URL from gh repo view: %q", payload.URL)
+	}
+	return parseRepository(u.Host + u.Path)
+}
+
+func parseRepository(raw string) (Repository, error) {
+	parts := strings.Split(strings.Trim(raw, "/"), "/")
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return Repository{}, fmt.Errorf("invalid repository %q: expected owner/repo", raw)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return Repository{Owner: parts[0], Repo: parts[1]}, nil
+	case 3:
+		return Repository{Owner: parts[1], Repo: parts[2], Host: strings.ToLower(parts[0])}, nil
+	default:
+		return Repository{}, fmt.Errorf("invalid repository %q: expected owner/repo", raw)
+	}
+}
@@ -0,0 +1,180 @@
+// Package sarif reads and writes the subset of SARIF 2.1.0 needed to exchange
+// review findings with static-analysis tooling.
+package sarif
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Version and Schema identify the SARIF dialect produced by this package.
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Log is the top-level SARIF document.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema,omitempty"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run groups the results produced by one tool invocation.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analysis tool.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the tool component that produced the results.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Rules          []Rule `json:"rules,omitempty"`
+}
+
+// Rule describes one reporting rule.
+type Rule struct {
+	ID               string   `json:"id"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+	HelpURI          string   `json:"helpUri,omitempty"`
+}
+
+// Result is a single finding.
+type Result struct {
+	RuleID              string                 `json:"ruleId,omitempty"`
+	Level               string                 `json:"level,omitempty"`
+	Message             Message                `json:"message"`
+	Locations           []Location             `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	HostedViewerURI     string                 `json:"hostedViewerUri,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Message carries human-readable text.
+type Message struct {
+	Text     string `json:"text,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// Location points at a region of an artifact.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation identifies an artifact and region within it.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation identifies a file.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region identifies a line range.
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// Finding is a flattened SARIF result with a single location.
+type Finding struct {
+	Tool      string
+	RuleID    string
+	Level     string
+	Message   string
+	Path      string
+	StartLine int
+	EndLine   int
+}
+
+// Read decodes a SARIF log.
+func Read(r io.Reader) (Log, error) {
+	var log Log
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return Log{}, fmt.Errorf("decode sarif: %w", err)
+	}
+	if len(log.Runs) == 0 {
+		return Log{}, errors.New("sarif log contains no runs")
+	}
+	return log, nil
+}
+
+// Findings flattens every result with a line-level location. Paths are made
+// relative to root when they are absolute or file:// URIs under it.
+func Findings(log Log, root string) []Finding {
+	findings := make([]Finding, 0)
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 {
+				continue
+			}
+			location := result.Locations[0].PhysicalLocation
+			if location.Region == nil || location.Region.StartLine <= 0 {
+				continue
+			}
+			path := normalizePath(location.ArtifactLocation.URI, root)
+			if path == "" {
+				continue
+			}
+
+			end := location.Region.EndLine
+			if end < location.Region.StartLine {
+				end = location.Region.StartLine
+			}
+			message := result.Message.Text
+			if message == "" {
+				message = result.Message.Markdown
+			}
+			level := result.Level
+			if level == "" {
+				level = "warning"
+			}
+
+			findings = append(findings, Finding{
+				Tool:      run.Tool.Driver.Name,
+				RuleID:    result.RuleID,
+				Level:     level,
+				Message:   message,
+				Path:      path,
+				StartLine: location.Region.StartLine,
+				EndLine:   end,
+			})
+		}
+	}
+	return findings
+}
+
+func normalizePath(uri, root string) string {
+	uri = strings.TrimSpace(uri)
+	if strings.HasPrefix(uri, "file://") {
+		if u, err := url.Parse(uri); err == nil {
+			uri = u.Path
+		}
+	} else if decoded, err := url.PathUnescape(uri); err == nil {
+		uri = decoded
+	}
+
+	if filepath.IsAbs(uri) && root != "" {
+		if rel, err := filepath.Rel(root, uri); err == nil && !strings.HasPrefix(rel, "..") {
+			uri = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(uri), "./")
+}
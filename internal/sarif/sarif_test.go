@@ -0,0 +1,71 @@
+package sarif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		root string
+		want string
+	}{
+		{uri: "main.go", root: "/src/widgets", want: "main.go"},
+		{uri: "./cmd/main.go", root: "/src/widgets", want: "cmd/main.go"},
+		{uri: "cmd/my%20file.go", root: "/src/widgets", want: "cmd/my file.go"},
+		{uri: "/src/widgets/cmd/main.go", root: "/src/widgets", want: "cmd/main.go"},
+		{uri: "file:///src/widgets/cmd/main.go", root: "/src/widgets", want: "cmd/main.go"},
+		{uri: "file:///src/widgets/my%20file.go", root: "/src/widgets/", want: "my file.go"},
+		{uri: "/elsewhere/main.go", root: "/src/widgets", want: "/elsewhere/main.go"},
+		{uri: "/src/widgets-old/main.go", root: "/src/widgets", want: "/src/widgets-old/main.go"},
+		{uri: "/src/widgets/main.go", root: "", want: "/src/widgets/main.go"},
+		{uri: "  ", root: "/src/widgets", want: ""},
+	}
+	for _, tt := range tests {
+		if got := normalizePath(tt.uri, tt.root); got != tt.want {
+			t.Errorf("normalizePath(%q, %q) = %q, want %q", tt.uri, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestFindingsFlattensResults(t *testing.T) {
+	log, err := Read(strings.NewReader(`{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "vet"}},
+    "results": [
+      {"ruleId": "unused", "level": "error", "message": {"text": "x is unused"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///src/widgets/main.go"}, "region": {"startLine": 3, "endLine": 5}}}]},
+      {"ruleId": "shadow", "message": {"markdown": "*shadowed*"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "util.go"}, "region": {"startLine": 7}}}]},
+      {"ruleId": "no-location", "message": {"text": "skipped"}},
+      {"ruleId": "no-region", "message": {"text": "skipped"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go"}}}]},
+      {"ruleId": "file-level", "message": {"text": "skipped"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go"}, "region": {"startColumn": 2}}}]}
+    ]
+  }]
+}`))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	want := []Finding{
+		{Tool: "vet", RuleID: "unused", Level: "error", Message: "x is unused", Path: "main.go", StartLine: 3, EndLine: 5},
+		{Tool: "vet", RuleID: "shadow", Level: "warning", Message: "*shadowed*", Path: "util.go", StartLine: 7, EndLine: 7},
+	}
+	if got := Findings(log, "/src/widgets"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("findings = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadRejectsEmptyLogs(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"version": "2.1.0", "runs": []}`)); err == nil {
+		t.Fatal("expected an error for a log without runs")
+	}
+	if _, err := Read(strings.NewReader(`not json`)); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}